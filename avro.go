@@ -0,0 +1,330 @@
+package csv2json
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// avroMagic starts every Avro object container file
+var avroMagic = []byte("Obj\x01")
+
+// identifier matches the names of Avro records and fields and of protobuf messages and fields
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// avroBlockSize is the size of serialized records at which a block is written
+const avroBlockSize = 64 * 1024
+
+// avroField describes a field of the Avro schema derived from the mapping. Nested properties become fields of
+// record type.
+type avroField struct {
+	name string
+
+	// avroType is the primitive type of the field or record for nested properties
+	avroType string
+
+	// nullable fields are a union of null and their type
+	nullable bool
+
+	// recordName is the full name of a record
+	recordName string
+
+	// fields holds the fields of a record
+	fields []*avroField
+}
+
+// avroSchema returns the schema of the records derived from the declared properties and their mapping types.
+// Properties of record types and optional columns are nullable.
+func (m *Mapper) avroSchema(recordOrder []string) (*avroField, error) {
+	if !identifier.MatchString(m.avroName) {
+		return nil, fmt.Errorf("%q is not a valid Avro record name", m.avroName)
+	}
+	root := &avroField{avroType: "record", recordName: m.avroName}
+	types := m.propertyTypes()
+	nullable := m.nullableProperties()
+	for _, property := range csvColumns(recordOrder) {
+		parent := root
+		path := strings.Split(property, ".")
+		for i, name := range path {
+			if !identifier.MatchString(name) {
+				return nil, fmt.Errorf("%q of property %q is not a valid Avro field name", name, property)
+			}
+			var field *avroField
+			for _, f := range parent.fields {
+				if f.name == name {
+					field = f
+				}
+			}
+			if field == nil {
+				field = &avroField{name: name, avroType: "record", recordName: parent.recordName + "_" + name}
+				if i == len(path)-1 {
+					field.avroType, field.nullable = avroType(types[property]), nullable[property]
+				}
+				parent.fields = append(parent.fields, field)
+			}
+			parent = field
+		}
+	}
+	return root, nil
+}
+
+// avroType returns the Avro primitive type of a mapping type.
+func avroType(t string) string {
+	switch t {
+	case "int":
+		return "long"
+	case "float":
+		return "double"
+	case "bool":
+		return "boolean"
+	}
+	return "string"
+}
+
+// nullableProperties returns the properties that may be missing in a record: optional columns of the mapping and
+// the properties of record types not provided by the mapping.
+func (m *Mapper) nullableProperties() map[string]bool {
+	nullable := make(map[string]bool)
+	required := make(map[string]bool)
+	for _, column := range m.configuration.Mapping {
+		if column.Optional {
+			nullable[column.Property] = true
+		} else {
+			required[column.Property] = true
+		}
+	}
+	for _, field := range m.configuration.Calculated {
+		required[field.Property] = true
+	}
+	for _, recordType := range m.configuration.RecordTypes.Types {
+		if recordType.Location == "document" {
+			continue
+		}
+		for _, column := range recordType.Mapping {
+			nullable[column.Property] = !required[column.Property]
+		}
+		for _, field := range recordType.Calculated {
+			nullable[field.Property] = !required[field.Property]
+		}
+	}
+	return nullable
+}
+
+// schema returns the JSON representation of the field type.
+func (f *avroField) schema() any {
+	var t any = f.avroType
+	if f.avroType == "record" {
+		fields := make([]any, len(f.fields))
+		for i, field := range f.fields {
+			definition := orderedMap{keys: []string{"name", "type"}, values: map[string]any{"name": field.name, "type": field.schema()}}
+			if field.nullable {
+				definition.keys = append(definition.keys, "default")
+				definition.values["default"] = nil
+			}
+			fields[i] = definition
+		}
+		t = orderedMap{keys: []string{"type", "name", "fields"}, values: map[string]any{"type": "record", "name": f.recordName, "fields": fields}}
+	}
+	if f.nullable {
+		return []any{"null", t}
+	}
+	return t
+}
+
+// encode writes the Avro binary encoding of a value of the field.
+func (f *avroField) encode(buf *bytes.Buffer, value any, property string) error {
+	if f.nullable {
+		if value == nil {
+			avroLong(buf, 0)
+			return nil
+		}
+		avroLong(buf, 1)
+	} else if value == nil && f.avroType != "record" {
+		return fmt.Errorf("property %q is missing, mark the column as optional", property)
+	}
+	switch f.avroType {
+	case "record":
+		record, ok := value.(map[string]any)
+		if !ok && value != nil {
+			return fmt.Errorf("property %q must be an object, found %T", property, value)
+		}
+		for _, field := range f.fields {
+			name := field.name
+			if property != "" {
+				name = property + "." + field.name
+			}
+			if err := field.encode(buf, record[field.name], name); err != nil {
+				return err
+			}
+		}
+	case "long":
+		v, ok := value.(int)
+		if !ok {
+			return fmt.Errorf("property %q must be an int, found %T", property, value)
+		}
+		avroLong(buf, int64(v))
+	case "double":
+		var v float64
+		switch n := value.(type) {
+		case float64:
+			v = n
+		case int:
+			v = float64(n)
+		default:
+			return fmt.Errorf("property %q must be a float, found %T", property, value)
+		}
+		buf.Write(binary.LittleEndian.AppendUint64(nil, math.Float64bits(v)))
+	case "boolean":
+		v, ok := value.(bool)
+		if !ok {
+			return fmt.Errorf("property %q must be a bool, found %T", property, value)
+		}
+		if v {
+			buf.WriteByte(1)
+		} else {
+			buf.WriteByte(0)
+		}
+	default:
+		text, ok := value.(string)
+		if !ok {
+			// objects and arrays are written as JSON text
+			d, err := jsonEncoder{decimals: -1}.marshal(value)
+			if err != nil {
+				return err
+			}
+			text = string(d)
+		}
+		avroString(buf, text)
+	}
+	return nil
+}
+
+// avroLong writes a zig-zag encoded variable-length long.
+func avroLong(buf *bytes.Buffer, value int64) {
+	buf.Write(binary.AppendUvarint(nil, uint64((value<<1)^(value>>63))))
+}
+
+// avroString writes the length of a string followed by its bytes.
+func avroString(buf *bytes.Buffer, value string) {
+	avroLong(buf, int64(len(value)))
+	buf.WriteString(value)
+}
+
+// avroWriter writes records as Avro object container file. Records are collected in blocks that are written once
+// they reach avroBlockSize, compressed with the configured codec.
+type avroWriter struct {
+	mapper *Mapper
+	out    io.Writer
+	schema *avroField
+
+	// sync is the marker written after every block
+	sync [16]byte
+
+	// block holds the serialized records of the current block
+	block bytes.Buffer
+	count int
+}
+
+// newAvroWriter derives the schema, writes it to the schema file or standard output if requested and writes the
+// file header.
+func (m *Mapper) newAvroWriter(out io.Writer, recordOrder []string) (*avroWriter, error) {
+	schema, err := m.avroSchema(recordOrder)
+	if err != nil {
+		return nil, err
+	}
+	if m.avroSchemaFile != "" {
+		d, err := jsonEncoder{indent: 2, decimals: -1}.marshal(schema.schema())
+		if err != nil {
+			return nil, err
+		}
+		if m.avroSchemaFile == "-" {
+			// the records are written to a file, so the schema can be printed
+			_, err = os.Stdout.Write(append(d, '\n'))
+		} else {
+			err = os.WriteFile(m.avroSchemaFile, append(d, '\n'), 0600)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	w := &avroWriter{mapper: m, out: out, schema: schema}
+	if _, err := rand.Read(w.sync[:]); err != nil {
+		return nil, err
+	}
+	d, err := jsonEncoder{decimals: -1}.marshal(schema.schema())
+	if err != nil {
+		return nil, err
+	}
+	var header bytes.Buffer
+	header.Write(avroMagic)
+	// file metadata is a map of bytes in a single block
+	avroLong(&header, 2)
+	avroString(&header, "avro.schema")
+	avroString(&header, string(d))
+	avroString(&header, "avro.codec")
+	avroString(&header, m.avroCodec)
+	avroLong(&header, 0)
+	header.Write(w.sync[:])
+	if _, err := out.Write(header.Bytes()); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// write adds the record to the current block and writes the block once it is full.
+func (w *avroWriter) write(record map[string]any) error {
+	if err := w.schema.encode(&w.block, record, ""); err != nil {
+		return err
+	}
+	w.count++
+	if w.block.Len() >= avroBlockSize {
+		return w.flush()
+	}
+	return nil
+}
+
+// close writes the last block.
+func (w *avroWriter) close(map[string]any) error {
+	return w.flush()
+}
+
+// flush writes the current block: the number of records, the size of the compressed records, the records and
+// the sync marker.
+func (w *avroWriter) flush() error {
+	if w.count == 0 {
+		return nil
+	}
+	data := w.block.Bytes()
+	if w.mapper.avroCodec == "deflate" {
+		var compressed bytes.Buffer
+		fw, err := flate.NewWriter(&compressed, flate.DefaultCompression)
+		if err != nil {
+			return err
+		}
+		if _, err = fw.Write(data); err != nil {
+			return err
+		}
+		if err = fw.Close(); err != nil {
+			return err
+		}
+		data = compressed.Bytes()
+	}
+	var header bytes.Buffer
+	avroLong(&header, int64(w.count))
+	avroLong(&header, int64(len(data)))
+	for _, d := range [][]byte{header.Bytes(), data, w.sync[:]} {
+		if _, err := w.out.Write(d); err != nil {
+			return err
+		}
+	}
+	w.block.Reset()
+	w.count = 0
+	return nil
+}
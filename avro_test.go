@@ -0,0 +1,233 @@
+package csv2json
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestMapAvroOutput tests writing records as Avro object container file.
+func TestMapAvroOutput(t *testing.T) {
+	mappingJSON := `{
+		"mapping": {
+			"0": {"property": "id", "type": "int"},
+			"1": {"property": "name.first", "type": "string"},
+			"2": {"property": "name.last", "type": "string", "optional": true},
+			"3": {"property": "price", "type": "float"},
+			"4": {"property": "active", "type": "bool"}
+		}
+	}`
+	input := "1,Jane,Doe,1.5,true\n2,Joe,,-2,false\n"
+	wantSchema := `{"type":"record","name":"record","fields":[{"name":"id","type":"long"},` +
+		`{"name":"name","type":{"type":"record","name":"record_name","fields":[{"name":"first","type":"string"},{"name":"last","type":["null","string"],"default":null}]}},` +
+		`{"name":"price","type":"double"},{"name":"active","type":"boolean"}]}`
+	wantRecords := "02084a616e650206446f65000000000000f83f01" + "04064a6f650000000000000000c000"
+
+	tests := []struct {
+		name    string
+		options []OptionFunc
+		codec   string
+		schema  string
+		wantErr bool
+	}{
+		{name: "null codec", codec: "null", schema: wantSchema},
+		{name: "deflate codec", options: []OptionFunc{WithAvroCodec("deflate")}, codec: "deflate", schema: wantSchema},
+		{
+			name:    "record name",
+			options: []OptionFunc{WithAvroName("customer")},
+			codec:   "null",
+			schema: `{"type":"record","name":"customer","fields":[{"name":"id","type":"long"},` +
+				`{"name":"name","type":{"type":"record","name":"customer_name","fields":[{"name":"first","type":"string"},{"name":"last","type":["null","string"],"default":null}]}},` +
+				`{"name":"price","type":"double"},{"name":"active","type":"boolean"}]}`,
+		},
+		{name: "invalid record name", options: []OptionFunc{WithAvroName("my-record")}, wantErr: true},
+		{name: "unknown codec", options: []OptionFunc{WithAvroCodec("snappy")}, wantErr: true},
+		{name: "array", options: []OptionFunc{WithArray(true)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := mapToFile(t, mappingJSON, input, append([]OptionFunc{WithOutputType("avro")}, tt.options...)...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Map() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			metadata, count, records := readAvroFile(t, data)
+			if metadata["avro.schema"] != tt.schema {
+				t.Errorf("schema = %s, want %s", metadata["avro.schema"], tt.schema)
+			}
+			if metadata["avro.codec"] != tt.codec {
+				t.Errorf("codec = %s, want %s", metadata["avro.codec"], tt.codec)
+			}
+			if count != 2 {
+				t.Errorf("count = %d, want 2", count)
+			}
+			if got := hex.EncodeToString(records); got != wantRecords {
+				t.Errorf("records = %s, want %s", got, wantRecords)
+			}
+		})
+	}
+}
+
+// TestAvroSchemaFile tests writing the derived schema to a file or standard output.
+func TestAvroSchemaFile(t *testing.T) {
+	want := `{
+  "type": "record",
+  "name": "record",
+  "fields": [
+    {
+      "name": "id",
+      "type": [
+        "null",
+        "long"
+      ],
+      "default": null
+    }
+  ]
+}
+`
+	tests := []struct {
+		name       string
+		schemaFile string
+		stdout     bool
+		wantErr    bool
+	}{
+		{
+			name:       "file",
+			schemaFile: "record.avsc",
+		},
+		{
+			name:       "standard output",
+			schemaFile: "-",
+		},
+		{
+			name:       "standard output with records on standard output",
+			schemaFile: "-",
+			stdout:     true,
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schemaFile := tt.schemaFile
+			if schemaFile != "-" {
+				schemaFile = filepath.Join(t.TempDir(), schemaFile)
+			}
+			options := []OptionFunc{WithOutputType("avro"), WithAvroSchemaFile(schemaFile)}
+			if tt.stdout {
+				options = append(options, WithOut("-"))
+			}
+
+			// capture the schema printed to standard output
+			oldStdout := os.Stdout
+			r, w, _ := os.Pipe()
+			os.Stdout = w
+			_, err := mapToFile(t, `{"mapping": {"0": {"property": "id", "type": "int", "optional": true}}}`, "1\n\n", options...)
+			w.Close()
+			os.Stdout = oldStdout
+			printed, _ := io.ReadAll(r)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Map() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			data := printed
+			if schemaFile != "-" {
+				if data, err = os.ReadFile(schemaFile); err != nil {
+					t.Fatalf("Failed to read schema file: %v", err)
+				}
+			}
+			if string(data) != want {
+				t.Errorf("schema = %s, want %s", data, want)
+			}
+		})
+	}
+}
+
+// TestAvroFieldEncode tests values not matching the schema.
+func TestAvroFieldEncode(t *testing.T) {
+	tests := []struct {
+		name  string
+		field *avroField
+		value any
+	}{
+		{name: "missing", field: &avroField{name: "id", avroType: "long"}, value: nil},
+		{name: "long", field: &avroField{name: "id", avroType: "long"}, value: "1"},
+		{name: "double", field: &avroField{name: "price", avroType: "double"}, value: true},
+		{name: "boolean", field: &avroField{name: "active", avroType: "boolean"}, value: 1},
+		{name: "record", field: &avroField{name: "name", avroType: "record"}, value: "Jane"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tt.field.encode(&buf, tt.value, tt.field.name); err == nil {
+				t.Error("encode() expected error")
+			}
+		})
+	}
+}
+
+// readAvroFile reads an object container file and returns its metadata, the number of records and the
+// decompressed records of all blocks.
+func readAvroFile(t *testing.T, data []byte) (map[string]string, int, []byte) {
+	t.Helper()
+	r := bufio.NewReader(bytes.NewReader(data))
+	readLong := func() int64 {
+		n, err := binary.ReadUvarint(r)
+		if err != nil {
+			t.Fatalf("Failed to read long: %v", err)
+		}
+		return int64(n>>1) ^ -int64(n&1)
+	}
+	readBytes := func(n int64) []byte {
+		b := make([]byte, n)
+		if _, err := io.ReadFull(r, b); err != nil {
+			t.Fatalf("Failed to read bytes: %v", err)
+		}
+		return b
+	}
+	if magic := readBytes(4); !bytes.Equal(magic, avroMagic) {
+		t.Fatalf("magic = %q", magic)
+	}
+	metadata := make(map[string]string)
+	for n := readLong(); n != 0; n = readLong() {
+		for ; n > 0; n-- {
+			key := string(readBytes(readLong()))
+			metadata[key] = string(readBytes(readLong()))
+		}
+	}
+	sync := readBytes(16)
+	count := 0
+	var records []byte
+	for {
+		if _, err := r.Peek(1); err == io.EOF {
+			break
+		}
+		count += int(readLong())
+		block := readBytes(readLong())
+		if metadata["avro.codec"] == "deflate" {
+			var err error
+			block, err = io.ReadAll(flate.NewReader(bytes.NewReader(block)))
+			if err != nil {
+				t.Fatalf("Failed to inflate block: %v", err)
+			}
+		}
+		records = append(records, block...)
+		if marker := readBytes(16); !bytes.Equal(marker, sync) {
+			t.Fatalf("sync marker = %x, want %x", marker, sync)
+		}
+	}
+	return metadata, count, records
+}
@@ -0,0 +1,249 @@
+package csv2json
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"slices"
+)
+
+// binaryFormat writes the primitives of a binary serialization format like MessagePack or CBOR.
+type binaryFormat interface {
+
+	// encodeNil writes a nil value
+	encodeNil(buf *bytes.Buffer)
+
+	// encodeBool writes a boolean
+	encodeBool(buf *bytes.Buffer, value bool)
+
+	// encodeInt writes a signed integer
+	encodeInt(buf *bytes.Buffer, value int64)
+
+	// encodeUint writes an unsigned integer
+	encodeUint(buf *bytes.Buffer, value uint64)
+
+	// encodeFloat writes a float with the given precision in bits, 32 or 64
+	encodeFloat(buf *bytes.Buffer, value float64, bits int)
+
+	// encodeString writes a UTF-8 string
+	encodeString(buf *bytes.Buffer, value string)
+
+	// arrayHeader starts an array of n elements, an array of unknown length if n is negative
+	arrayHeader(buf *bytes.Buffer, n int)
+
+	// mapHeader starts a map of n pairs, a map of unknown length if n is negative
+	mapHeader(buf *bytes.Buffer, n int)
+
+	// indefinite reports whether arrays and maps of unknown length are supported
+	indefinite() bool
+
+	// end ends an array or map of unknown length
+	end(buf *bytes.Buffer)
+}
+
+// encodeBinary writes v in the binary format. Object keys are written in sorted order unless the object is an
+// orderedMap. Ints, floats, booleans and strings keep their type.
+func encodeBinary(f binaryFormat, buf *bytes.Buffer, v any) error {
+	switch value := v.(type) {
+	case nil:
+		f.encodeNil(buf)
+	case string:
+		f.encodeString(buf, value)
+	case bool:
+		f.encodeBool(buf, value)
+	case int:
+		f.encodeInt(buf, int64(value))
+	case int64:
+		f.encodeInt(buf, value)
+	case uint64:
+		f.encodeUint(buf, value)
+	case float64:
+		f.encodeFloat(buf, value, 64)
+	case float32:
+		f.encodeFloat(buf, float64(value), 32)
+	case json.Number:
+		if i, err := value.Int64(); err == nil {
+			f.encodeInt(buf, i)
+			return nil
+		}
+		fl, err := value.Float64()
+		if err != nil {
+			return err
+		}
+		f.encodeFloat(buf, fl, 64)
+	case map[string]any:
+		if value == nil {
+			f.encodeNil(buf)
+			return nil
+		}
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		return encodeBinaryMap(f, buf, keys, value)
+	case orderedMap:
+		return encodeBinaryMap(f, buf, value.keys, value.values)
+	case []map[string]any:
+		return encodeBinaryArray(f, buf, value)
+	case []any:
+		return encodeBinaryArray(f, buf, value)
+	case []string:
+		return encodeBinaryArray(f, buf, value)
+	default:
+		// other values are converted by the standard library to their generic JSON representation
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		var generic any
+		if err := decoder.Decode(&generic); err != nil {
+			return err
+		}
+		return encodeBinary(f, buf, generic)
+	}
+	return nil
+}
+
+// encodeBinaryMap writes the pairs of a map in the order of keys.
+func encodeBinaryMap(f binaryFormat, buf *bytes.Buffer, keys []string, values map[string]any) error {
+	f.mapHeader(buf, len(keys))
+	for _, key := range keys {
+		f.encodeString(buf, key)
+		if err := encodeBinary(f, buf, values[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeBinaryArray writes the elements of a slice as an array.
+func encodeBinaryArray[T any](f binaryFormat, buf *bytes.Buffer, values []T) error {
+	if values == nil {
+		f.encodeNil(buf)
+		return nil
+	}
+	f.arrayHeader(buf, len(values))
+	for _, value := range values {
+		if err := encodeBinary(f, buf, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// binaryWriter writes records in a binary format. Without -array the records are written as a sequence of
+// values. As array the records are written as array of unknown length if the format supports it, otherwise
+// they are spooled to a temporary file until their number is known. With a property the array is the first
+// entry of a map holding the document-level properties.
+type binaryWriter struct {
+	mapper        *Mapper
+	format        binaryFormat
+	out           io.Writer
+	property      string
+	recordOrder   []string
+	documentOrder []string
+
+	// spool holds the records of an array while their number is not yet known
+	spool *os.File
+
+	written int
+	buf     bytes.Buffer
+}
+
+// newBinaryWriter creates a binaryWriter and writes the start of an array of unknown length.
+func (m *Mapper) newBinaryWriter(out io.Writer, format binaryFormat, recordOrder, documentOrder []string) (*binaryWriter, error) {
+	w := &binaryWriter{mapper: m, format: format, out: out, property: m.documentProperty(), recordOrder: recordOrder, documentOrder: documentOrder}
+	if !m.array {
+		return w, nil
+	}
+	if !format.indefinite() {
+		spool, err := os.CreateTemp("", "csv2json-*.bin")
+		if err != nil {
+			return nil, err
+		}
+		w.spool = spool
+		return w, nil
+	}
+	if w.property != "" {
+		format.mapHeader(&w.buf, -1)
+		format.encodeString(&w.buf, w.property)
+	}
+	format.arrayHeader(&w.buf, -1)
+	if _, err := out.Write(w.buf.Bytes()); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// write encodes the record as the next value of the sequence or array.
+func (w *binaryWriter) write(record map[string]any) error {
+	w.buf.Reset()
+	if err := encodeBinary(w.format, &w.buf, w.mapper.order(record, w.recordOrder)); err != nil {
+		return err
+	}
+	w.written++
+	out := w.out
+	if w.spool != nil {
+		out = w.spool
+	}
+	_, err := out.Write(w.buf.Bytes())
+	return err
+}
+
+// close ends the array and writes the remaining document properties.
+func (w *binaryWriter) close(document map[string]any) error {
+	if !w.mapper.array {
+		return nil
+	}
+	var keys []string
+	var values map[string]any
+	if w.property != "" {
+		keys, values = members(w.mapper.order(document, w.documentOrder))
+		keys = slices.DeleteFunc(slices.Clone(keys), func(key string) bool { return key == w.property })
+	}
+	w.buf.Reset()
+	if w.spool != nil {
+		if w.property != "" {
+			w.format.mapHeader(&w.buf, len(keys)+1)
+			w.format.encodeString(&w.buf, w.property)
+		}
+		w.format.arrayHeader(&w.buf, w.written)
+		if _, err := w.out.Write(w.buf.Bytes()); err != nil {
+			return err
+		}
+		if _, err := w.spool.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		if _, err := io.Copy(w.out, w.spool); err != nil {
+			return err
+		}
+		w.buf.Reset()
+	} else {
+		w.format.end(&w.buf)
+	}
+	for _, key := range keys {
+		w.format.encodeString(&w.buf, key)
+		if err := encodeBinary(w.format, &w.buf, values[key]); err != nil {
+			return err
+		}
+	}
+	if w.spool == nil && w.property != "" {
+		w.format.end(&w.buf)
+	}
+	_, err := w.out.Write(w.buf.Bytes())
+	return err
+}
+
+// removeSpool closes and removes the spool file, if any.
+func (w *binaryWriter) removeSpool() {
+	if w.spool == nil {
+		return
+	}
+	_ = w.spool.Close()
+	_ = os.Remove(w.spool.Name())
+	w.spool = nil
+}
@@ -0,0 +1,68 @@
+package csv2json
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// TestMapBinaryOutput tests writing records as MessagePack and CBOR sequences and arrays.
+func TestMapBinaryOutput(t *testing.T) {
+	mappingJSON := `{
+		"mapping": {
+			"0": {"property": "id", "type": "int"},
+			"1": {"property": "name", "type": "string"},
+			"2": {"property": "price", "type": "float"},
+			"3": {"property": "active", "type": "bool"}
+		},
+		"calculated": [
+			{"property": "total", "kind": "application", "format": "records", "type": "int", "location": "document"}
+		]
+	}`
+	input := "1,a,1.5,true\n2,b,-2,false\n"
+
+	// records with the properties active, id, name and price
+	msgpack1 := "84a6616374697665c3a2696401a46e616d65a161a57072696365cb3ff8000000000000"
+	msgpack2 := "84a6616374697665c2a2696402a46e616d65a162a57072696365cbc000000000000000"
+	cbor1 := "a466616374697665f562696401646e616d656161657072696365fb3ff8000000000000"
+	cbor2 := "a466616374697665f462696402646e616d656162657072696365fbc000000000000000"
+
+	tests := []struct {
+		name       string
+		outputType string
+		input      string
+		options    []OptionFunc
+		want       string
+	}{
+		{name: "msgpack sequence", outputType: "msgpack", input: input, want: msgpack1 + msgpack2},
+		{name: "msgpack array", outputType: "msgpack", input: input, options: []OptionFunc{WithArray(true)}, want: "92" + msgpack1 + msgpack2},
+		{name: "msgpack empty array", outputType: "msgpack", options: []OptionFunc{WithArray(true)}, want: "90"},
+		{
+			name:       "msgpack nested",
+			outputType: "msgpack",
+			input:      input,
+			options:    []OptionFunc{WithArray(true), WithNestedPropertyName("records")},
+			want:       "82a77265636f72647392" + msgpack1 + msgpack2 + "a5746f74616c02",
+		},
+		{name: "cbor sequence", outputType: "cbor", input: input, want: cbor1 + cbor2},
+		{name: "cbor array", outputType: "cbor", input: input, options: []OptionFunc{WithArray(true)}, want: "9f" + cbor1 + cbor2 + "ff"},
+		{
+			name:       "cbor nested",
+			outputType: "cbor",
+			input:      input,
+			options:    []OptionFunc{WithArray(true), WithNestedPropertyName("records")},
+			want:       "bf677265636f7264739f" + cbor1 + cbor2 + "ff65746f74616c02ff",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := mapToFile(t, mappingJSON, tt.input, append([]OptionFunc{WithOutputType(tt.outputType)}, tt.options...)...)
+			if err != nil {
+				t.Fatalf("Map() error = %v", err)
+			}
+			if got := hex.EncodeToString(data); got != tt.want {
+				t.Errorf("Map() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package csv2json
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// bulkWriter writes records in the NDJSON format of the Elasticsearch and OpenSearch _bulk API. Every record
+// becomes an action line followed by the document. Output is split into chunk files once a chunk reaches the
+// configured number of documents or bytes.
+type bulkWriter struct {
+	mapper *Mapper
+	out    io.Writer
+
+	// encoder writes single line JSON regardless of -indent
+	encoder jsonEncoder
+
+	// order holds the declared order of record properties
+	order []string
+
+	// chunk is the number of the current chunk, starting at 1 for the output file
+	chunk int
+
+	// documents and bytes hold the size of the current chunk
+	documents, bytes int
+
+	// file and buffered hold the current chunk file after the first chunk
+	file     *os.File
+	buffered *bufio.Writer
+}
+
+// newBulkWriter creates a bulkWriter and validates the properties providing index and id.
+func (m *Mapper) newBulkWriter(out io.Writer, recordOrder []string) (*bulkWriter, error) {
+	for _, property := range []string{m.bulkIndexProperty, m.bulkIDProperty} {
+		if property != "" && !slices.Contains(recordOrder, property) {
+			return nil, fmt.Errorf("bulk property %q is not a property of the records", property)
+		}
+	}
+	encoder := m.encoder
+	encoder.indent = 0
+	return &bulkWriter{mapper: m, out: out, encoder: encoder, order: recordOrder, chunk: 1}, nil
+}
+
+// write writes the action line and the document of a record, starting a new chunk if the current one is full.
+func (w *bulkWriter) write(record map[string]any) error {
+	action, err := w.action(record)
+	if err != nil {
+		return err
+	}
+	var document any = w.mapper.order(record, w.order)
+	if w.mapper.bulkAction == "update" {
+		document = map[string]any{"doc": document}
+	}
+	d, err := w.encoder.marshal(document)
+	if err != nil {
+		return err
+	}
+	entry := append(append(append(action, '\n'), d...), '\n')
+	if w.full(len(entry)) {
+		if err := w.nextChunk(); err != nil {
+			return err
+		}
+	}
+	w.documents++
+	w.bytes += len(entry)
+	_, err = w.out.Write(entry)
+	return err
+}
+
+// action returns the action line of a record. _index and _id are omitted if the record has no value for them.
+func (w *bulkWriter) action(record map[string]any) ([]byte, error) {
+	values := make(map[string]any)
+	flattenRecord(record, "", values)
+	metadata := orderedMap{values: make(map[string]any)}
+	for _, field := range []struct{ name, property string }{{"_index", w.mapper.bulkIndexProperty}, {"_id", w.mapper.bulkIDProperty}} {
+		if field.property == "" || values[field.property] == nil {
+			continue
+		}
+		var text string
+		switch v := values[field.property].(type) {
+		case string:
+			text = v
+		case int:
+			text = strconv.Itoa(v)
+		default:
+			return nil, fmt.Errorf("%s property %q must be a string or an int, found %T", field.name, field.property, v)
+		}
+		metadata.keys = append(metadata.keys, field.name)
+		metadata.values[field.name] = text
+	}
+	if w.mapper.bulkAction == "update" && metadata.values["_id"] == nil {
+		return nil, errors.New("update action requires an _id")
+	}
+	return w.encoder.marshal(orderedMap{keys: []string{w.mapper.bulkAction}, values: map[string]any{w.mapper.bulkAction: metadata}})
+}
+
+// full reports whether adding an entry of the given size exceeds the limits of the current chunk. A chunk
+// always takes at least one document.
+func (w *bulkWriter) full(size int) bool {
+	if w.documents == 0 {
+		return false
+	}
+	if w.mapper.bulkMaxDocuments > 0 && w.documents >= w.mapper.bulkMaxDocuments {
+		return true
+	}
+	return w.mapper.bulkMaxBytes > 0 && w.bytes+size > w.mapper.bulkMaxBytes
+}
+
+// nextChunk closes the current chunk file and opens the next one.
+func (w *bulkWriter) nextChunk() error {
+	if err := w.closeChunk(); err != nil {
+		return err
+	}
+	w.chunk++
+	file, err := os.OpenFile(bulkChunkName(w.mapper.out, w.chunk), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	w.file = file
+	w.buffered = bufio.NewWriter(file)
+	w.out = w.buffered
+	w.documents, w.bytes = 0, 0
+	return nil
+}
+
+// bulkChunkName returns the file name of a chunk. The first chunk is written to the output file, later chunks
+// append their number to the name, so out.ndjson is followed by out-2.ndjson.
+func bulkChunkName(out string, chunk int) string {
+	if chunk == 1 {
+		return out
+	}
+	ext := filepath.Ext(out)
+	return strings.TrimSuffix(out, ext) + "-" + strconv.Itoa(chunk) + ext
+}
+
+// closeChunk flushes and closes the current chunk file. The output file of the first chunk is closed by Map.
+func (w *bulkWriter) closeChunk() error {
+	if w.file == nil {
+		return nil
+	}
+	err := w.buffered.Flush()
+	if closeErr := w.file.Close(); err == nil {
+		err = closeErr
+	}
+	w.file = nil
+	return err
+}
+
+// close closes the last chunk file.
+func (w *bulkWriter) close(map[string]any) error {
+	return w.closeChunk()
+}
@@ -0,0 +1,107 @@
+package csv2json
+
+import (
+	"os"
+	"testing"
+)
+
+// TestMapBulkOutput tests writing records in the _bulk NDJSON format and splitting the output into chunks.
+func TestMapBulkOutput(t *testing.T) {
+	mappingJSON := `{
+		"mapping": {
+			"0": {"property": "id", "type": "int"},
+			"1": {"property": "name", "type": "string"}
+		},
+		"calculated": [
+			{"property": "index", "kind": "extra", "format": "index", "type": "string", "location": "record"}
+		],
+		"extra_variables": {"index": {"value": "products"}}
+	}`
+	input := "1,Jane\n2,Joe\n3,Ann\n"
+
+	tests := []struct {
+		name    string
+		options []OptionFunc
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "index without metadata",
+			want: []string{"{\"index\":{}}\n{\"id\":1,\"index\":\"products\",\"name\":\"Jane\"}\n" +
+				"{\"index\":{}}\n{\"id\":2,\"index\":\"products\",\"name\":\"Joe\"}\n" +
+				"{\"index\":{}}\n{\"id\":3,\"index\":\"products\",\"name\":\"Ann\"}\n"},
+		},
+		{
+			name:    "create with index and id",
+			options: []OptionFunc{WithBulkAction("create"), WithBulkIndexProperty("index"), WithBulkIDProperty("id"), WithIndent(2)},
+			want: []string{"{\"create\":{\"_index\":\"products\",\"_id\":\"1\"}}\n{\"id\":1,\"index\":\"products\",\"name\":\"Jane\"}\n" +
+				"{\"create\":{\"_index\":\"products\",\"_id\":\"2\"}}\n{\"id\":2,\"index\":\"products\",\"name\":\"Joe\"}\n" +
+				"{\"create\":{\"_index\":\"products\",\"_id\":\"3\"}}\n{\"id\":3,\"index\":\"products\",\"name\":\"Ann\"}\n"},
+		},
+		{
+			name:    "update split by documents",
+			options: []OptionFunc{WithBulkAction("update"), WithBulkIDProperty("id"), WithBulkMaxDocuments(2)},
+			want: []string{
+				"{\"update\":{\"_id\":\"1\"}}\n{\"doc\":{\"id\":1,\"index\":\"products\",\"name\":\"Jane\"}}\n" +
+					"{\"update\":{\"_id\":\"2\"}}\n{\"doc\":{\"id\":2,\"index\":\"products\",\"name\":\"Joe\"}}\n",
+				"{\"update\":{\"_id\":\"3\"}}\n{\"doc\":{\"id\":3,\"index\":\"products\",\"name\":\"Ann\"}}\n",
+			},
+		},
+		{
+			name:    "split by bytes",
+			options: []OptionFunc{WithBulkMaxBytes(60)},
+			want: []string{
+				"{\"index\":{}}\n{\"id\":1,\"index\":\"products\",\"name\":\"Jane\"}\n",
+				"{\"index\":{}}\n{\"id\":2,\"index\":\"products\",\"name\":\"Joe\"}\n",
+				"{\"index\":{}}\n{\"id\":3,\"index\":\"products\",\"name\":\"Ann\"}\n",
+			},
+		},
+		{
+			name:    "update without id",
+			options: []OptionFunc{WithBulkAction("update")},
+			wantErr: true,
+		},
+		{
+			name:    "unknown property",
+			options: []OptionFunc{WithBulkIDProperty("code")},
+			wantErr: true,
+		},
+		{
+			name:    "unknown action",
+			options: []OptionFunc{WithBulkAction("delete")},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := mapFiles(t, mappingJSON, input, append([]OptionFunc{WithOutputType("bulk")}, tt.options...)...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Map() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			for i, want := range tt.want {
+				data, err := os.ReadFile(bulkChunkName(out, i+1))
+				if err != nil {
+					t.Fatalf("Failed to read chunk %d: %v", i+1, err)
+				}
+				if string(data) != want {
+					t.Errorf("chunk %d = %q, want %q", i+1, data, want)
+				}
+			}
+			if _, err := os.Stat(bulkChunkName(out, len(tt.want)+1)); !os.IsNotExist(err) {
+				t.Errorf("unexpected chunk %d", len(tt.want)+1)
+			}
+		})
+	}
+}
+
+// TestBulkOutputRequiresFile tests that split output can not be written to stdout.
+func TestBulkOutputRequiresFile(t *testing.T) {
+	_, err := NewMapper(WithOut("-"), WithOutputType("bulk"), WithBulkMaxDocuments(10))
+	if err == nil {
+		t.Error("NewMapper() expected error for split output to stdout")
+	}
+}
@@ -0,0 +1,113 @@
+package csv2json
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+)
+
+// major types of CBOR data items
+const (
+	cborUnsigned = 0
+	cborNegative = 1
+	cborText     = 3
+	cborArray    = 4
+	cborMap      = 5
+)
+
+// cborFormat writes CBOR as defined by RFC 8949. Integers and lengths use the shortest head, arrays and maps
+// of unknown length use the indefinite-length encoding.
+type cborFormat struct{}
+
+// encodeNil writes null.
+func (cborFormat) encodeNil(buf *bytes.Buffer) {
+	buf.WriteByte(0xf6)
+}
+
+// encodeBool writes false or true.
+func (cborFormat) encodeBool(buf *bytes.Buffer, value bool) {
+	if value {
+		buf.WriteByte(0xf5)
+	} else {
+		buf.WriteByte(0xf4)
+	}
+}
+
+// encodeInt writes an unsigned or negative integer.
+func (cborFormat) encodeInt(buf *bytes.Buffer, value int64) {
+	if value < 0 {
+		cborHead(buf, cborNegative, uint64(-1-value))
+		return
+	}
+	cborHead(buf, cborUnsigned, uint64(value))
+}
+
+// encodeUint writes an unsigned integer.
+func (cborFormat) encodeUint(buf *bytes.Buffer, value uint64) {
+	cborHead(buf, cborUnsigned, value)
+}
+
+// encodeFloat writes a single or double precision float.
+func (cborFormat) encodeFloat(buf *bytes.Buffer, value float64, bits int) {
+	if bits == 32 {
+		buf.WriteByte(0xfa)
+		buf.Write(binary.BigEndian.AppendUint32(nil, math.Float32bits(float32(value))))
+		return
+	}
+	buf.WriteByte(0xfb)
+	buf.Write(binary.BigEndian.AppendUint64(nil, math.Float64bits(value)))
+}
+
+// encodeString writes a text string.
+func (cborFormat) encodeString(buf *bytes.Buffer, value string) {
+	cborHead(buf, cborText, uint64(len(value)))
+	buf.WriteString(value)
+}
+
+// arrayHeader writes the head of an array, of unknown length if n is negative.
+func (cborFormat) arrayHeader(buf *bytes.Buffer, n int) {
+	if n < 0 {
+		buf.WriteByte(0x9f)
+		return
+	}
+	cborHead(buf, cborArray, uint64(n))
+}
+
+// mapHeader writes the head of a map, of unknown length if n is negative.
+func (cborFormat) mapHeader(buf *bytes.Buffer, n int) {
+	if n < 0 {
+		buf.WriteByte(0xbf)
+		return
+	}
+	cborHead(buf, cborMap, uint64(n))
+}
+
+// indefinite reports true, CBOR supports arrays and maps of unknown length.
+func (cborFormat) indefinite() bool {
+	return true
+}
+
+// end writes the break ending an array or map of unknown length.
+func (cborFormat) end(buf *bytes.Buffer) {
+	buf.WriteByte(0xff)
+}
+
+// cborHead writes the initial byte of a data item and its argument in the shortest form.
+func cborHead(buf *bytes.Buffer, major byte, argument uint64) {
+	major <<= 5
+	switch {
+	case argument < 24:
+		buf.WriteByte(major | byte(argument))
+	case argument <= math.MaxUint8:
+		buf.Write([]byte{major | 24, byte(argument)})
+	case argument <= math.MaxUint16:
+		buf.WriteByte(major | 25)
+		buf.Write(binary.BigEndian.AppendUint16(nil, uint16(argument)))
+	case argument <= math.MaxUint32:
+		buf.WriteByte(major | 26)
+		buf.Write(binary.BigEndian.AppendUint32(nil, uint32(argument)))
+	default:
+		buf.WriteByte(major | 27)
+		buf.Write(binary.BigEndian.AppendUint64(nil, argument))
+	}
+}
@@ -0,0 +1,47 @@
+package csv2json
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// TestCBORFormat tests the CBOR encoding of single values using examples of RFC 8949.
+func TestCBORFormat(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+		want  string
+	}{
+		{name: "0", value: 0, want: "00"},
+		{name: "23", value: 23, want: "17"},
+		{name: "24", value: 24, want: "1818"},
+		{name: "1000", value: 1000, want: "1903e8"},
+		{name: "1000000", value: 1000000, want: "1a000f4240"},
+		{name: "1000000000000", value: 1000000000000, want: "1b000000e8d4a51000"},
+		{name: "-1", value: -1, want: "20"},
+		{name: "-100", value: -100, want: "3863"},
+		{name: "-1000", value: -1000, want: "3903e7"},
+		{name: "1.1", value: 1.1, want: "fb3ff199999999999a"},
+		{name: "float 32", value: float32(100000.0), want: "fa47c35000"},
+		{name: "false", value: false, want: "f4"},
+		{name: "true", value: true, want: "f5"},
+		{name: "null", value: nil, want: "f6"},
+		{name: "text", value: "IETF", want: "6449455446"},
+		{name: "empty array", value: []any{}, want: "80"},
+		{name: "array", value: []any{1, 2, 3}, want: "83010203"},
+		{name: "map", value: map[string]any{"a": 1, "b": []any{2, 3}}, want: "a26161016162820203"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := encodeBinary(cborFormat{}, &buf, tt.value); err != nil {
+				t.Fatalf("encodeBinary() error = %v", err)
+			}
+			if got := hex.EncodeToString(buf.Bytes()); got != tt.want {
+				t.Errorf("encodeBinary() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,13 +1,30 @@
 package main
 
 import (
+	"os"
+	"strings"
+
 	"github.com/sascha-andres/reuse/flag"
 
 	"github.com/sascha-andres/csv2json"
 )
 
+// inputs collects the values of repeated -in flags
+type inputs []string
+
+// String returns the inputs as a comma separated list
+func (i *inputs) String() string {
+	return strings.Join(*i, ",")
+}
+
+// Set adds an input to the list
+func (i *inputs) Set(value string) error {
+	*i = append(*i, value)
+	return nil
+}
+
 var (
-	in                 string
+	in                 inputs
 	out                string
 	array              bool
 	named              bool
@@ -15,24 +32,114 @@ var (
 	outputType         string
 	nestedPropertyName string
 	separator          string = ","
+	inputType          string
+	sheet              string
+	skipLines          int
+	skipUntil          string
+	headerRow          int
+	skipTrailer        int
+	trailerPattern     string
+	indent             int
+	noHTMLEscape       bool
+	ascii              bool
+	noExponent         bool
+	floatDecimals      int
+	ordered            bool
+	yamlStream         bool
+	yamlDocument       string
+	recordElement      string
+	headerElement      string
+	outputSeparator    string
+	flattenSeparator   string
+	quoting            string
+	allowFormulas      bool
+	dialect            string
+	table              string
+	batchSize          int
+	upsertKeys         string
+	createTable        bool
+	copyFormat         string
+	bulkAction         string
+	bulkIndexProperty  string
+	bulkIDProperty     string
+	bulkMaxDocuments   int
+	bulkMaxBytes       int
+	avroCodec          string
+	avroName           string
+	avroSchema         string
+	protoMessage       string
+	protoFile          string
+	templateFile       string
+	maxWidth           int
+	keyBy              string
+	keySeparator       string
+	duplicateKeys      string
 )
 
 // init initializes the command-line flags and environment variables.
 func init() {
 	flag.SetEnvPrefix("CSV2JSON")
-	flag.StringVar(&in, "in", "-", "input file, defaults to stdin")
+	flag.Var(&in, "in", "input file or glob pattern, may be repeated, defaults to stdin")
 	flag.StringVar(&out, "out", "-", "output file, defaults to stdout")
 	flag.BoolVar(&array, "array", false, "output as array (implicit for yaml and toml)")
 	flag.BoolVar(&named, "named", false, "output as named")
 	flag.StringVar(&mappingFile, "mapping", "mapping.json", "mapping file")
-	flag.StringVar(&outputType, "output-type", "json", "output type, one of json, yaml or toml")
+	flag.StringVar(&outputType, "output-type", "json", "output type, one of json, yaml, toml, xml, csv, sql, pgcopy, bulk, geojson, msgpack, cbor, avro, protobuf, template, markdown, html or table")
 	flag.StringVar(&nestedPropertyName, "nested-property", "", "property name for nested array output")
 	flag.StringVar(&separator, "separator", ",", "separator for CSV input")
+	flag.StringVar(&inputType, "input-type", "csv", "input type, one of csv, fixed-width, jsonl, json or ods")
+	flag.StringVar(&sheet, "sheet", "", "name or 1-based index of the sheet to read from ods input, defaults to the first sheet")
+	flag.IntVar(&skipLines, "skip-lines", 0, "number of lines to skip at the beginning of every input")
+	flag.StringVar(&skipUntil, "skip-until", "", "skip lines at the beginning of every input until a line matches this regular expression")
+	flag.IntVar(&headerRow, "header-row", 1, "row of the header (requires -named), rows before are dropped")
+	flag.IntVar(&skipTrailer, "skip-trailer", 0, "number of rows to drop at the end of every input")
+	flag.StringVar(&trailerPattern, "trailer-pattern", "", "drop rows matching this regular expression")
+	flag.IntVar(&indent, "indent", 0, "number of spaces to indent json output, 0 for compact output")
+	flag.BoolVar(&noHTMLEscape, "no-html-escape", false, "do not escape <, > and & in json output")
+	flag.BoolVar(&ascii, "ascii", false, "escape all non-ASCII characters in json output")
+	flag.BoolVar(&noExponent, "no-exponent", false, "write floats in json output without exponent notation")
+	flag.BoolVar(&ordered, "ordered", false, "write properties in the order they are declared in the mapping")
+	flag.BoolVar(&yamlStream, "yaml-stream", false, "write every record as a separate document of a yaml stream instead of an array")
+	flag.StringVar(&yamlDocument, "yaml-document", "", "write document-level fields as a leading or trailing document of a yaml stream")
+	flag.StringVar(&recordElement, "record-element", "record", "name of the element of every record in xml output")
+	flag.StringVar(&headerElement, "header-element", "header", "name of the element holding document-level fields in xml output")
+	flag.StringVar(&outputSeparator, "output-separator", ",", "separator for csv output")
+	flag.StringVar(&flattenSeparator, "flatten-separator", ".", "separator joining nested properties in the header of csv output")
+	flag.StringVar(&quoting, "quoting", "minimal", "cells quoted in csv output, one of minimal, strings or all")
+	flag.BoolVar(&allowFormulas, "allow-formulas", false, "do not neutralize text cells of csv output starting like a spreadsheet formula")
+	flag.StringVar(&dialect, "dialect", "postgres", "dialect of sql output, one of postgres, mysql or sqlite")
+	flag.StringVar(&table, "table", "data", "table sql output inserts into")
+	flag.IntVar(&batchSize, "batch-size", 100, "number of records per INSERT statement of sql output")
+	flag.StringVar(&upsertKeys, "upsert-keys", "", "comma separated columns identifying existing rows, turns sql output into upserts")
+	flag.BoolVar(&createTable, "create-table", false, "write a CREATE TABLE statement derived from the mapping before sql output")
+	flag.StringVar(&copyFormat, "copy-format", "text", "format of pgcopy output, one of text or binary")
+	flag.StringVar(&bulkAction, "bulk-action", "index", "action of bulk output, one of index, create or update")
+	flag.StringVar(&bulkIndexProperty, "bulk-index-property", "", "property providing the _index of bulk actions")
+	flag.StringVar(&bulkIDProperty, "bulk-id-property", "", "property providing the _id of bulk actions")
+	flag.IntVar(&bulkMaxDocuments, "bulk-max-documents", 0, "maximum number of documents per bulk output file, 0 for no limit")
+	flag.IntVar(&bulkMaxBytes, "bulk-max-bytes", 0, "maximum size in bytes of a bulk output file, 0 for no limit")
+	flag.StringVar(&avroCodec, "avro-codec", "null", "compression codec of avro output, one of null or deflate")
+	flag.StringVar(&avroName, "avro-name", "record", "name of the avro record schema")
+	flag.StringVar(&avroSchema, "avro-schema", "", "file to write the avro schema derived from the mapping to, - for stdout")
+	flag.StringVar(&protoMessage, "proto-message", "Record", "name of the protobuf message of the records")
+	flag.StringVar(&protoFile, "proto-file", "", "file to write the .proto definition derived from the mapping to")
+	flag.StringVar(&templateFile, "template", "", "text/template file of template output")
+	flag.IntVar(&maxWidth, "max-width", 0, "maximum width of table columns, longer cells are truncated, 0 for no limit")
+	flag.StringVar(&keyBy, "key-by", "", "comma separated properties keying the records of an object written instead of an array")
+	flag.StringVar(&keySeparator, "key-separator", ":", "separator joining the values of composite keys")
+	flag.StringVar(&duplicateKeys, "duplicate-keys", "error", "policy for records sharing a key, one of error, first, last or array")
+	flag.IntVar(&floatDecimals, "float-decimals", -1, "fixed number of decimals for floats in json output, -1 for the shortest representation")
 }
 
 // main parses flags, executes the application logic via the run function, and handles any errors by panicking.
 func main() {
 	flag.Parse()
+	if len(in) == 0 {
+		in = inputs{"-"}
+		if v, ok := os.LookupEnv("CSV2JSON_IN"); ok && v != "" {
+			in = inputs{v}
+		}
+	}
 
 	if err := run(); err != nil {
 		panic(err)
@@ -42,15 +149,63 @@ func main() {
 // run initializes a Mapper instance with provided configurations and processes CSV input into JSON format.
 // It returns an error if any step in the process fails.
 func run() error {
-	m, err := csv2json.NewMapper(
+	options := []csv2json.OptionFunc{
 		csv2json.WithOutputType(outputType),
 		csv2json.WithOut(out),
 		csv2json.WithArray(array),
-		csv2json.WithIn(in),
+		csv2json.WithIn(in...),
 		csv2json.WithMappingFile(mappingFile),
 		csv2json.WithNamed(named),
 		csv2json.WithNestedPropertyName(nestedPropertyName),
-		csv2json.WithSeparator(separator))
+		csv2json.WithSeparator(separator),
+		csv2json.WithInputType(inputType),
+		csv2json.WithSheet(sheet),
+		csv2json.WithSkipLines(skipLines),
+		csv2json.WithSkipUntil(skipUntil),
+		csv2json.WithHeaderRow(headerRow),
+		csv2json.WithSkipTrailer(skipTrailer),
+		csv2json.WithTrailerPattern(trailerPattern),
+		csv2json.WithIndent(indent),
+		csv2json.WithNoHTMLEscape(noHTMLEscape),
+		csv2json.WithASCII(ascii),
+		csv2json.WithNoExponent(noExponent),
+		csv2json.WithFloatDecimals(floatDecimals),
+		csv2json.WithOrdered(ordered),
+		csv2json.WithYAMLStream(yamlStream),
+		csv2json.WithYAMLDocument(yamlDocument),
+		csv2json.WithRecordElement(recordElement),
+		csv2json.WithHeaderElement(headerElement),
+		csv2json.WithOutputSeparator(outputSeparator),
+		csv2json.WithFlattenSeparator(flattenSeparator),
+		csv2json.WithQuoting(quoting),
+		csv2json.WithAllowFormulas(allowFormulas),
+		csv2json.WithDialect(dialect),
+		csv2json.WithTable(table),
+		csv2json.WithBatchSize(batchSize),
+		csv2json.WithCreateTable(createTable),
+		csv2json.WithCopyFormat(copyFormat),
+		csv2json.WithBulkAction(bulkAction),
+		csv2json.WithBulkIndexProperty(bulkIndexProperty),
+		csv2json.WithBulkIDProperty(bulkIDProperty),
+		csv2json.WithBulkMaxDocuments(bulkMaxDocuments),
+		csv2json.WithBulkMaxBytes(bulkMaxBytes),
+		csv2json.WithAvroCodec(avroCodec),
+		csv2json.WithAvroName(avroName),
+		csv2json.WithAvroSchemaFile(avroSchema),
+		csv2json.WithProtoMessage(protoMessage),
+		csv2json.WithProtoFile(protoFile),
+		csv2json.WithTemplate(templateFile),
+		csv2json.WithMaxWidth(maxWidth),
+		csv2json.WithKeySeparator(keySeparator),
+		csv2json.WithDuplicateKeys(duplicateKeys),
+	}
+	if upsertKeys != "" {
+		options = append(options, csv2json.WithUpsertKeys(strings.Split(upsertKeys, ",")...))
+	}
+	if keyBy != "" {
+		options = append(options, csv2json.WithKeyBy(strings.Split(keyBy, ",")...))
+	}
+	m, err := csv2json.NewMapper(options...)
 
 	if err != nil {
 		return err
@@ -0,0 +1,158 @@
+package csv2json
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// csvWriter writes records as rows of a CSV file. Nested properties are flattened, the header lists the declared
+// properties of the mapping.
+type csvWriter struct {
+	mapper *Mapper
+	out    io.Writer
+
+	// columns holds the dotted property paths written as columns
+	columns []string
+
+	buf bytes.Buffer
+}
+
+// newCSVWriter creates a csvWriter and writes the header.
+func (m *Mapper) newCSVWriter(out io.Writer, recordOrder []string) (*csvWriter, error) {
+	w := &csvWriter{mapper: m, out: out, columns: csvColumns(recordOrder)}
+	header := make([]any, len(w.columns))
+	for i, column := range w.columns {
+		header[i] = strings.ReplaceAll(column, ".", m.flattenSeparator)
+	}
+	if err := w.writeRow(header); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// csvColumns returns the properties of order without duplicates. Properties that are parents of other
+// properties are dropped, as their values are written as separate columns.
+func csvColumns(order []string) []string {
+	var columns []string
+	seen := make(map[string]bool)
+	for _, property := range order {
+		if seen[property] {
+			continue
+		}
+		seen[property] = true
+		parent := false
+		for _, other := range order {
+			if strings.HasPrefix(other, property+".") {
+				parent = true
+				break
+			}
+		}
+		if !parent {
+			columns = append(columns, property)
+		}
+	}
+	return columns
+}
+
+// write flattens the record and writes it as a row.
+func (w *csvWriter) write(record map[string]any) error {
+	values := make(map[string]any)
+	flattenRecord(record, "", values)
+	row := make([]any, len(w.columns))
+	for i, column := range w.columns {
+		row[i] = values[column]
+	}
+	return w.writeRow(row)
+}
+
+// close does nothing, as every row is complete.
+func (w *csvWriter) close(map[string]any) error {
+	return nil
+}
+
+// flattenRecord adds the values of a record to values using dotted property paths.
+func flattenRecord(record map[string]any, prefix string, values map[string]any) {
+	for key, value := range record {
+		if nested, ok := value.(map[string]any); ok {
+			flattenRecord(nested, prefix+key+".", values)
+			continue
+		}
+		values[prefix+key] = value
+	}
+}
+
+// writeRow writes the values of a single row followed by a newline.
+func (w *csvWriter) writeRow(row []any) error {
+	w.buf.Reset()
+	for i, value := range row {
+		if i > 0 {
+			w.buf.WriteRune(w.mapper.outputSeparator)
+		}
+		text, isString, err := w.mapper.cellText(value)
+		if err != nil {
+			return err
+		}
+		if isString && !w.mapper.allowFormulas && isFormula(text) {
+			// a leading quote makes spreadsheet applications treat the cell as text
+			text = "'" + text
+		}
+		if w.quote(text, isString) {
+			w.buf.WriteByte('"')
+			w.buf.WriteString(strings.ReplaceAll(text, `"`, `""`))
+			w.buf.WriteByte('"')
+		} else {
+			w.buf.WriteString(text)
+		}
+	}
+	w.buf.WriteByte('\n')
+	_, err := w.out.Write(w.buf.Bytes())
+	return err
+}
+
+// cellText returns the cell text of a value and whether it is a string. Floats use the JSON float options,
+// objects and arrays are written as JSON.
+func (m *Mapper) cellText(value any) (string, bool, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", false, nil
+	case string:
+		return v, true, nil
+	case bool:
+		return strconv.FormatBool(v), false, nil
+	case int:
+		return strconv.Itoa(v), false, nil
+	case float64:
+		var buf bytes.Buffer
+		if err := m.encoder.encodeFloat(&buf, v, 64); err != nil {
+			return "", false, err
+		}
+		return buf.String(), false, nil
+	}
+	d, err := m.encoder.marshal(value)
+	return string(d), true, err
+}
+
+// quote reports whether a cell is quoted. minimal quotes cells containing the separator, quotes, line breaks
+// or leading spaces, strings additionally quotes all strings and all quotes every cell.
+func (w *csvWriter) quote(text string, isString bool) bool {
+	switch w.mapper.quoting {
+	case "all":
+		return true
+	case "strings":
+		if isString {
+			return true
+		}
+	}
+	if text == "" {
+		return false
+	}
+	return strings.ContainsRune(text, w.mapper.outputSeparator) || strings.ContainsAny(text, "\"\r\n") ||
+		text[0] == ' ' || text[0] == '\t'
+}
+
+// isFormula reports whether a spreadsheet application would interpret the text as a formula.
+func isFormula(text string) bool {
+	return text != "" && strings.ContainsRune("=+-@\t\r", rune(text[0]))
+}
@@ -0,0 +1,103 @@
+package csv2json
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestMapCSVOutput tests writing flattened records as CSV.
+func TestMapCSVOutput(t *testing.T) {
+	mappingJSON := `{
+		"mapping": {
+			"0": {"property": "id", "type": "int"},
+			"1": {"property": "name.last", "type": "string"},
+			"2": {"property": "name.first", "type": "string"},
+			"3": {"property": "price", "type": "float"}
+		},
+		"calculated": [
+			{"property": "active", "kind": "extra", "format": "active", "type": "bool", "location": "record"}
+		],
+		"extra_variables": {"active": {"value": "true"}}
+	}`
+
+	tests := []struct {
+		name    string
+		input   string
+		options []OptionFunc
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "defaults",
+			input: "1,Doe,Jane,1.5\n2,\"Smith, Jr.\",\"Joe \"\"J\"\"\",2\n",
+			want:  "id,name.last,name.first,price,active\n1,Doe,Jane,1.5,true\n2,\"Smith, Jr.\",\"Joe \"\"J\"\"\",2,true\n",
+		},
+		{
+			name:    "separators and decimals",
+			input:   "1,Doe,Jane,1.5\n",
+			options: []OptionFunc{WithOutputSeparator(";"), WithFlattenSeparator("_"), WithFloatDecimals(2)},
+			want:    "id;name_last;name_first;price;active\n1;Doe;Jane;1.50;true\n",
+		},
+		{
+			name:    "quote strings",
+			input:   "1,Doe,,1.5\n",
+			options: []OptionFunc{WithQuoting("strings")},
+			want:    "\"id\",\"name.last\",\"name.first\",\"price\",\"active\"\n1,\"Doe\",\"\",1.5,true\n",
+		},
+		{
+			name:    "quote all",
+			input:   "1,Doe,Jane,1.5\n",
+			options: []OptionFunc{WithQuoting("all")},
+			want:    "\"id\",\"name.last\",\"name.first\",\"price\",\"active\"\n\"1\",\"Doe\",\"Jane\",\"1.5\",\"true\"\n",
+		},
+		{
+			name:  "formula injection",
+			input: "-1,\"=HYPERLINK(\"\"x\"\")\",@SUM(A1),2\n",
+			want:  "id,name.last,name.first,price,active\n-1,\"'=HYPERLINK(\"\"x\"\")\",'@SUM(A1),2,true\n",
+		},
+		{
+			name:    "formulas allowed",
+			input:   "1,=1+1,+2,2\n",
+			options: []OptionFunc{WithAllowFormulas(true)},
+			want:    "id,name.last,name.first,price,active\n1,=1+1,+2,2,true\n",
+		},
+		{
+			name:    "unknown quoting",
+			input:   "1,Doe,Jane,1.5\n",
+			options: []OptionFunc{WithQuoting("unknown")},
+			wantErr: true,
+		},
+		{
+			name:    "array",
+			options: []OptionFunc{WithArray(true)},
+			wantErr: true,
+		},
+		{
+			name: "header only",
+			want: "id,name.last,name.first,price,active\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := mapToFile(t, mappingJSON, tt.input, append([]OptionFunc{WithOutputType("csv")}, tt.options...)...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Map() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if string(data) != tt.want {
+				t.Errorf("Map() = %q, want %q", data, tt.want)
+			}
+		})
+	}
+}
+
+// TestCSVColumns tests deriving the columns from the declared property order.
+func TestCSVColumns(t *testing.T) {
+	got := csvColumns([]string{"name", "id", "name.first", "id", "name.last"})
+	if want := "id,name.first,name.last"; strings.Join(got, ",") != want {
+		t.Errorf("csvColumns() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,138 @@
+package csv2json
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+)
+
+// validateSchemaDrift verifies the settings of the schema drift configuration.
+func (m *Mapper) validateSchemaDrift() error {
+	drift := m.configuration.SchemaDrift
+	for name, setting := range map[string]string{"missing": drift.Missing, "unmapped": drift.Unmapped, "duplicate": drift.Duplicate} {
+		switch setting {
+		case "", "ignore", "warn", "error":
+		default:
+			return fmt.Errorf("unknown schema_drift setting %q for %s, expected ignore, warn or error", setting, name)
+		}
+	}
+	return nil
+}
+
+// checkSchemaDrift compares the header of an input with the mapping. header contains the names as read,
+// keys the mapping keys they resolve to. Depending on the configuration differences are ignored,
+// reported as warnings or returned as error.
+func (m *Mapper) checkSchemaDrift(in string, header, keys []string) error {
+	drift := m.configuration.SchemaDrift
+	var errs []error
+	report := func(setting, format string, args ...any) {
+		switch setting {
+		case "warn":
+			m.warn("%s: schema drift: "+format, append([]any{in}, args...)...)
+		case "error":
+			errs = append(errs, fmt.Errorf("%s: schema drift: "+format, append([]any{in}, args...)...))
+		}
+	}
+
+	if drift.Missing == "warn" || drift.Missing == "error" {
+		for _, column := range m.expectedColumns() {
+			if !slices.Contains(keys, column) {
+				report(drift.Missing, "mapped column %q missing in header", column)
+			}
+		}
+	}
+	if drift.Unmapped == "warn" || drift.Unmapped == "error" {
+		expected := m.expectedColumns()
+		for i, key := range keys {
+			if !slices.Contains(expected, key) {
+				report(drift.Unmapped, "column %q is not mapped", header[i])
+			}
+		}
+	}
+	if drift.Duplicate == "warn" || drift.Duplicate == "error" {
+		for i, key := range keys {
+			if first := slices.Index(keys, key); first < i {
+				report(drift.Duplicate, "column %q duplicates column %q", header[i], header[first])
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// checkInputHeaders reads the header of every input and checks it for schema drift, so drift is reported before
+// any record is written. Standard input can only be read once, its header is checked when it is mapped.
+func (m *Mapper) checkInputHeaders(inputs []string) error {
+	if !m.named || !m.detectsSchemaDrift() {
+		return nil
+	}
+	var errs []error
+	for _, in := range inputs {
+		if in == "-" {
+			continue
+		}
+		if err := m.checkInputHeader(in); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// checkInputHeader reads the header of a single input and checks it for schema drift.
+func (m *Mapper) checkInputHeader(in string) error {
+	reader, err := openInput(in)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	records, err := m.newRecordReader(reader)
+	if err != nil {
+		return err
+	}
+	header, err := records.readHeader()
+	if err != nil {
+		return fmt.Errorf("%s: %w", in, err)
+	}
+	return m.checkSchemaDrift(in, header, m.headerKeys(header))
+}
+
+// detectsSchemaDrift reports whether any kind of schema drift is warned about or rejected.
+func (m *Mapper) detectsSchemaDrift() bool {
+	drift := m.configuration.SchemaDrift
+	for _, setting := range []string{drift.Missing, drift.Unmapped, drift.Duplicate} {
+		if setting == "warn" || setting == "error" {
+			return true
+		}
+	}
+	return false
+}
+
+// expectedColumns returns the sorted mapping keys and source fields of mapping calculated fields.
+func (m *Mapper) expectedColumns() []string {
+	columns := make([]string, 0, len(m.configuration.Mapping))
+	for key := range m.configuration.Mapping {
+		columns = append(columns, key)
+	}
+	for _, field := range m.configuration.Calculated {
+		if field.Kind != "mapping" {
+			continue
+		}
+		source, _, _ := strings.Cut(field.Format, ":")
+		if column := m.columnKey(source); !slices.Contains(columns, column) {
+			columns = append(columns, column)
+		}
+	}
+	slices.Sort(columns)
+	return columns
+}
+
+// warn writes a warning to the configured warnings writer.
+func (m *Mapper) warn(format string, args ...any) {
+	var w io.Writer = os.Stderr
+	if m.warnings != nil {
+		w = m.warnings
+	}
+	_, _ = fmt.Fprintf(w, "warning: "+format+"\n", args...)
+}
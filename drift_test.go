@@ -0,0 +1,149 @@
+package csv2json
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestCheckSchemaDrift tests reporting missing, unmapped and duplicate columns as warnings or errors.
+func TestCheckSchemaDrift(t *testing.T) {
+	mapping := map[string]ColumnConfiguration{
+		"id":   {Property: "id", Type: "int"},
+		"name": {Property: "name", Aliases: []string{"Name"}},
+	}
+	calculated := []CalculatedField{
+		{Property: "code", Kind: "mapping", Format: "status:a=1,default=0", Type: "int", Location: "record"},
+	}
+
+	tests := []struct {
+		name         string
+		drift        SchemaDriftConfiguration
+		header       []string
+		wantWarnings []string
+		wantErrors   []string
+	}{
+		{
+			name:   "ignored by default",
+			header: []string{"id", "id", "other"},
+		},
+		{
+			name:   "matching header",
+			drift:  SchemaDriftConfiguration{Missing: "error", Unmapped: "error", Duplicate: "error"},
+			header: []string{"status", "Name", "id"},
+		},
+		{
+			name:         "missing columns as warning",
+			drift:        SchemaDriftConfiguration{Missing: "warn"},
+			header:       []string{"id"},
+			wantWarnings: []string{`mapped column "name" missing`, `mapped column "status" missing`},
+		},
+		{
+			name:       "unmapped column as error",
+			drift:      SchemaDriftConfiguration{Unmapped: "error"},
+			header:     []string{"id", "name", "status", "added"},
+			wantErrors: []string{`column "added" is not mapped`},
+		},
+		{
+			name:         "duplicate through alias",
+			drift:        SchemaDriftConfiguration{Duplicate: "error", Unmapped: "warn"},
+			header:       []string{"id", "name", "status", "Name", "x"},
+			wantWarnings: []string{`column "x" is not mapped`},
+			wantErrors:   []string{`column "Name" duplicates column "name"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var warnings bytes.Buffer
+			m := &Mapper{
+				warnings: &warnings,
+				configuration: Configuration{
+					Mapping:     mapping,
+					Calculated:  calculated,
+					SchemaDrift: tt.drift,
+				},
+			}
+			if err := m.prepareColumns(); err != nil {
+				t.Fatalf("prepareColumns() error = %v", err)
+			}
+			err := m.checkSchemaDrift("input.csv", tt.header, m.headerKeys(tt.header))
+			if (err != nil) != (len(tt.wantErrors) > 0) {
+				t.Fatalf("checkSchemaDrift() error = %v, want %v", err, tt.wantErrors)
+			}
+			for _, want := range tt.wantErrors {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("checkSchemaDrift() error = %v, should contain %v", err, want)
+				}
+			}
+			if got := strings.Count(warnings.String(), "\n"); got != len(tt.wantWarnings) {
+				t.Errorf("checkSchemaDrift() warnings = %q, want %d warnings", warnings.String(), len(tt.wantWarnings))
+			}
+			for _, want := range tt.wantWarnings {
+				if !strings.Contains(warnings.String(), want) {
+					t.Errorf("checkSchemaDrift() warnings = %q, should contain %v", warnings.String(), want)
+				}
+			}
+		})
+	}
+}
+
+// TestMapSchemaDriftFailsBeforeOutput tests that schema drift of any input is reported before records are written.
+func TestMapSchemaDriftFailsBeforeOutput(t *testing.T) {
+	mappingJSON := `{
+		"schema_drift": {"missing": "error"},
+		"mapping": {
+			"id": {"property": "id", "type": "int"},
+			"price": {"property": "price", "type": "float"}
+		}
+	}`
+
+	tests := []struct {
+		name   string
+		inputs []string
+	}{
+		{
+			name:   "single input",
+			inputs: []string{"id,cost\n1,2.5\n"},
+		},
+		{
+			name:   "drift in later input",
+			inputs: []string{"id,price\n1,2.5\n", "id,cost\n2,3.5\n"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			mappingFile := filepath.Join(dir, "mapping.json")
+			if err := os.WriteFile(mappingFile, []byte(mappingJSON), 0600); err != nil {
+				t.Fatalf("Failed to write mapping file: %v", err)
+			}
+			var inputs []string
+			for i, input := range tt.inputs {
+				in := filepath.Join(dir, fmt.Sprintf("input%d.csv", i))
+				if err := os.WriteFile(in, []byte(input), 0600); err != nil {
+					t.Fatalf("Failed to write input file: %v", err)
+				}
+				inputs = append(inputs, in)
+			}
+			out := filepath.Join(dir, "out.json")
+
+			mapper, err := NewMapper(WithIn(inputs...), WithOut(out), WithNamed(true), WithMappingFile(mappingFile), WithOutputType("json"))
+			if err != nil {
+				t.Fatalf("Failed to create mapper: %v", err)
+			}
+			err = mapper.Map()
+			if err == nil || !strings.Contains(err.Error(), `mapped column "price" missing in header`) {
+				t.Fatalf("Map() error = %v, want missing column error", err)
+			}
+			data, _ := os.ReadFile(out)
+			if len(data) != 0 {
+				t.Errorf("Map() wrote %q, want no output", data)
+			}
+		})
+	}
+}
@@ -0,0 +1,230 @@
+package csv2json
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// jsonEncoder encodes documents as JSON. Object keys are written in sorted order unless the object is an
+// orderedMap, so the output is stable across runs.
+type jsonEncoder struct {
+
+	// indent is the number of spaces used to indent nested values, 0 writes compact JSON
+	indent int
+
+	// noHTMLEscape disables escaping <, > and & in strings
+	noHTMLEscape bool
+
+	// ascii escapes all non-ASCII characters
+	ascii bool
+
+	// noExponent writes floats without exponent notation
+	noExponent bool
+
+	// decimals is the fixed number of decimals written for floats, -1 writes the shortest representation
+	decimals int
+}
+
+// marshal returns the JSON encoding of v.
+func (e jsonEncoder) marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := e.encode(&buf, v, 0); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// encode writes the JSON encoding of v at the given nesting depth.
+func (e jsonEncoder) encode(buf *bytes.Buffer, v any, depth int) error {
+	switch value := v.(type) {
+	case nil:
+		buf.WriteString("null")
+	case string:
+		e.encodeString(buf, value)
+	case bool:
+		buf.WriteString(strconv.FormatBool(value))
+	case int:
+		buf.WriteString(strconv.Itoa(value))
+	case int64:
+		buf.WriteString(strconv.FormatInt(value, 10))
+	case uint64:
+		buf.WriteString(strconv.FormatUint(value, 10))
+	case float64:
+		return e.encodeFloat(buf, value, 64)
+	case float32:
+		return e.encodeFloat(buf, float64(value), 32)
+	case json.Number:
+		buf.WriteString(value.String())
+	case map[string]any:
+		if value == nil {
+			buf.WriteString("null")
+			return nil
+		}
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		return e.encodeObject(buf, keys, value, depth)
+	case orderedMap:
+		return e.encodeObject(buf, value.keys, value.values, depth)
+	case []map[string]any:
+		return encodeArray(e, buf, value, depth)
+	case []any:
+		return encodeArray(e, buf, value, depth)
+	case []string:
+		return encodeArray(e, buf, value, depth)
+	default:
+		// other values are encoded by the standard library and re-encoded to apply the options
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		var generic any
+		if err := decoder.Decode(&generic); err != nil {
+			return err
+		}
+		return e.encode(buf, generic, depth)
+	}
+	return nil
+}
+
+// encodeObject writes the properties of an object in the order of keys.
+func (e jsonEncoder) encodeObject(buf *bytes.Buffer, keys []string, values map[string]any, depth int) error {
+	buf.WriteByte('{')
+	for i, key := range keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		e.newline(buf, depth+1)
+		e.encodeString(buf, key)
+		buf.WriteByte(':')
+		if e.indent > 0 {
+			buf.WriteByte(' ')
+		}
+		if err := e.encode(buf, values[key], depth+1); err != nil {
+			return err
+		}
+	}
+	if len(keys) > 0 {
+		e.newline(buf, depth)
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+// encodeArray writes the elements of a slice as a JSON array.
+func encodeArray[T any](e jsonEncoder, buf *bytes.Buffer, values []T, depth int) error {
+	if values == nil {
+		buf.WriteString("null")
+		return nil
+	}
+	buf.WriteByte('[')
+	for i, value := range values {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		e.newline(buf, depth+1)
+		if err := e.encode(buf, value, depth+1); err != nil {
+			return err
+		}
+	}
+	if len(values) > 0 {
+		e.newline(buf, depth)
+	}
+	buf.WriteByte(']')
+	return nil
+}
+
+// newline starts a new line indented for the given depth if indentation is enabled.
+func (e jsonEncoder) newline(buf *bytes.Buffer, depth int) {
+	if e.indent == 0 {
+		return
+	}
+	buf.WriteByte('\n')
+	buf.WriteString(strings.Repeat(" ", e.indent*depth))
+}
+
+// encodeFloat writes a float. Without options the format matches encoding/json, exponent notation is used for
+// very small and very large values.
+func (e jsonEncoder) encodeFloat(buf *bytes.Buffer, f float64, bits int) error {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return fmt.Errorf("unsupported float value %v", f)
+	}
+	if e.decimals >= 0 {
+		buf.WriteString(strconv.FormatFloat(f, 'f', e.decimals, bits))
+		return nil
+	}
+	format := byte('f')
+	if abs := math.Abs(f); !e.noExponent && abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		format = 'e'
+	}
+	b := strconv.AppendFloat(nil, f, format, -1, bits)
+	if format == 'e' {
+		// shorten e-09 to e-9 like encoding/json
+		n := len(b)
+		if n >= 4 && b[n-4] == 'e' && b[n-3] == '-' && b[n-2] == '0' {
+			b[n-2] = b[n-1]
+			b = b[:n-1]
+		}
+	}
+	buf.Write(b)
+	return nil
+}
+
+// encodeString writes a quoted string. Control characters, U+2028 and U+2029 are always escaped, HTML characters
+// unless disabled and non-ASCII characters if requested. Invalid UTF-8 is replaced by U+FFFD.
+func (e jsonEncoder) encodeString(buf *bytes.Buffer, s string) {
+	const hex = "0123456789abcdef"
+	escape := func(r rune) {
+		buf.WriteString(`\u`)
+		buf.WriteByte(hex[r>>12&0xf])
+		buf.WriteByte(hex[r>>8&0xf])
+		buf.WriteByte(hex[r>>4&0xf])
+		buf.WriteByte(hex[r&0xf])
+	}
+	buf.WriteByte('"')
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		switch {
+		case r == '"' || r == '\\':
+			buf.WriteByte('\\')
+			buf.WriteRune(r)
+		case r == '\n':
+			buf.WriteString(`\n`)
+		case r == '\r':
+			buf.WriteString(`\r`)
+		case r == '\t':
+			buf.WriteString(`\t`)
+		case r < 0x20:
+			escape(r)
+		case (r == '<' || r == '>' || r == '&') && !e.noHTMLEscape:
+			escape(r)
+		case r == '\u2028' || r == '\u2029':
+			escape(r)
+		case r == utf8.RuneError && size == 1:
+			escape(utf8.RuneError)
+		case r >= utf8.RuneSelf && e.ascii:
+			if r > 0xffff {
+				high, low := utf16.EncodeRune(r)
+				escape(high)
+				escape(low)
+			} else {
+				escape(r)
+			}
+		default:
+			buf.WriteRune(r)
+		}
+	}
+	buf.WriteByte('"')
+}
@@ -0,0 +1,140 @@
+package csv2json
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+// TestJSONEncoderMarshal tests the JSON encoding options.
+func TestJSONEncoderMarshal(t *testing.T) {
+	record := map[string]any{
+		"name":  "<Müller & Söhne> 😀",
+		"price": 12.5,
+		"large": 1e21,
+		"small": 0.0000001,
+		"count": 3,
+		"tags":  []any{"a", true, nil},
+	}
+
+	tests := []struct {
+		name    string
+		encoder jsonEncoder
+		value   any
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "defaults match encoding/json",
+			encoder: jsonEncoder{decimals: -1},
+			value:   record,
+			want:    `{"count":3,"large":1e+21,"name":"\u003cMüller \u0026 Söhne\u003e 😀","price":12.5,"small":1e-7,"tags":["a",true,null]}`,
+		},
+		{
+			name:    "no html escape",
+			encoder: jsonEncoder{decimals: -1, noHTMLEscape: true},
+			value:   map[string]any{"html": "<b>&</b>"},
+			want:    `{"html":"<b>&</b>"}`,
+		},
+		{
+			name:    "ascii",
+			encoder: jsonEncoder{decimals: -1, noHTMLEscape: true, ascii: true},
+			value:   "Müller 😀",
+			want:    `"M\u00fcller \ud83d\ude00"`,
+		},
+		{
+			name:    "no exponent",
+			encoder: jsonEncoder{decimals: -1, noExponent: true},
+			value:   []any{1e21, 0.0000001},
+			want:    `[1000000000000000000000,0.0000001]`,
+		},
+		{
+			name:    "fixed decimals",
+			encoder: jsonEncoder{decimals: 2},
+			value:   []any{12.5, 1.005, 3},
+			want:    `[12.50,1.00,3]`,
+		},
+		{
+			name:    "indent",
+			encoder: jsonEncoder{decimals: -1, indent: 2},
+			value:   map[string]any{"b": []map[string]any{{"c": 1}}, "a": map[string]any{}, "d": []any{}},
+			want:    "{\n  \"a\": {},\n  \"b\": [\n    {\n      \"c\": 1\n    }\n  ],\n  \"d\": []\n}",
+		},
+		{
+			name:    "control characters",
+			encoder: jsonEncoder{decimals: -1},
+			value:   "a\"\\\n\t\x01\u2028",
+			want:    `"a\"\\\n\t\u0001\u2028"`,
+		},
+		{
+			name:    "not a number",
+			encoder: jsonEncoder{decimals: -1},
+			value:   []any{json.Number("1"), math.NaN()},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.encoder.marshal(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("marshal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if string(got) != tt.want {
+				t.Errorf("marshal() = %s, want %s", got, tt.want)
+			}
+			if tt.encoder.decimals < 0 && !tt.encoder.noExponent && !json.Valid(got) {
+				t.Errorf("marshal() = %s, not valid JSON", got)
+			}
+		})
+	}
+}
+
+// TestMapWithJSONEncoding tests that the encoding options apply to separate documents and arrays.
+func TestMapWithJSONEncoding(t *testing.T) {
+	tests := []struct {
+		name   string
+		array  bool
+		nested string
+		want   string
+	}{
+		{
+			name: "documents",
+			want: "{\n  \"name\": \"<a>\",\n  \"price\": 1.50\n}\n{\n  \"name\": \"<b>\",\n  \"price\": 2.00\n}",
+		},
+		{
+			name:  "array",
+			array: true,
+			want:  "[\n  {\n    \"name\": \"<a>\",\n    \"price\": 1.50\n  },\n  {\n    \"name\": \"<b>\",\n    \"price\": 2.00\n  }\n]",
+		},
+		{
+			name:   "nested",
+			array:  true,
+			nested: "data",
+			want:   "{\n  \"data\": [\n    {\n      \"name\": \"<a>\",\n      \"price\": 1.50\n    },\n    {\n      \"name\": \"<b>\",\n      \"price\": 2.00\n    }\n  ]\n}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mappingJSON := `{"mapping": {"0": {"property": "name", "type": "string"}, "1": {"property": "price", "type": "float"}}}`
+			data, err := mapToFile(t, mappingJSON, "<a>,1.5\n<b>,2\n",
+				WithArray(tt.array),
+				WithOutputType("json"),
+				WithNestedPropertyName(tt.nested),
+				WithIndent(2),
+				WithNoHTMLEscape(true),
+				WithFloatDecimals(2),
+			)
+			if err != nil {
+				t.Fatalf("Failed to map: %v", err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("Map() = %s, want %s", data, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package csv2json
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// fixedWidthColumn is a column with its resolved position and padding rules.
+type fixedWidthColumn struct {
+	name    string
+	start   int
+	length  int
+	trim    string
+	padding string
+}
+
+// fixedWidthReader reads records from fixed width text input where every column occupies a fixed range of characters.
+type fixedWidthReader struct {
+	in      *bufio.Reader
+	columns []fixedWidthColumn
+}
+
+// newFixedWidthReader creates a fixedWidthReader, resolving column positions and trim rules from the configuration.
+func newFixedWidthReader(in io.Reader, configuration FixedWidthConfiguration) (*fixedWidthReader, error) {
+	if len(configuration.Columns) == 0 {
+		return nil, errors.New("fixed width input requires at least one column in fixed_width.columns")
+	}
+	columns := make([]fixedWidthColumn, len(configuration.Columns))
+	next := 0
+	for i, c := range configuration.Columns {
+		col := fixedWidthColumn{
+			name:    c.Name,
+			start:   next,
+			length:  c.Length,
+			trim:    configuration.Trim,
+			padding: configuration.Padding,
+		}
+		if c.Start != nil {
+			col.start = *c.Start
+		}
+		if c.Trim != "" {
+			col.trim = c.Trim
+		}
+		if c.Padding != "" {
+			col.padding = c.Padding
+		}
+		if col.padding == "" {
+			col.padding = " "
+		}
+		if col.start < 0 || col.length <= 0 {
+			return nil, fmt.Errorf("fixed width column %d (%q) requires a non negative start and a positive length", i, c.Name)
+		}
+		switch col.trim {
+		case "", "both", "left", "right", "none":
+		default:
+			return nil, fmt.Errorf("unknown trim %q for fixed width column %d (%q)", col.trim, i, c.Name)
+		}
+		columns[i] = col
+		next = col.start + col.length
+	}
+	return &fixedWidthReader{in: bufio.NewReader(in), columns: columns}, nil
+}
+
+// readHeader returns the column names from the configuration, fixed width input does not contain a header.
+func (f *fixedWidthReader) readHeader() ([]string, error) {
+	header := make([]string, len(f.columns))
+	for i, c := range f.columns {
+		if c.name == "" {
+			return nil, fmt.Errorf("fixed width column %d requires a name when reading named input", i)
+		}
+		header[i] = c.name
+	}
+	return header, nil
+}
+
+// read returns the columns of the next non-empty line.
+func (f *fixedWidthReader) read() ([]string, error) {
+	for {
+		line, err := f.in.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			continue
+		}
+		return f.split([]rune(line)), nil
+	}
+}
+
+// split cuts a line into its columns and removes padding. Columns beyond the end of the line are empty.
+func (f *fixedWidthReader) split(line []rune) []string {
+	record := make([]string, len(f.columns))
+	for i, c := range f.columns {
+		if c.start >= len(line) {
+			continue
+		}
+		end := min(c.start+c.length, len(line))
+		record[i] = trimPadding(string(line[c.start:end]), c.trim, c.padding)
+	}
+	return record
+}
+
+// trimPadding removes padding characters from the side(s) of a value denoted by trim.
+func trimPadding(val, trim, padding string) string {
+	switch trim {
+	case "none":
+		return val
+	case "left":
+		return strings.TrimLeft(val, padding)
+	case "right":
+		return strings.TrimRight(val, padding)
+	}
+	return strings.Trim(val, padding)
+}
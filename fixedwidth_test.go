@@ -0,0 +1,187 @@
+package csv2json
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestFixedWidthReader tests splitting fixed width lines into columns using positions, widths and padding rules.
+func TestFixedWidthReader(t *testing.T) {
+	start := func(i int) *int { return &i }
+
+	tests := []struct {
+		name          string
+		configuration FixedWidthConfiguration
+		input         string
+		want          [][]string
+		wantErr       bool
+	}{
+		{
+			name: "widths only",
+			configuration: FixedWidthConfiguration{
+				Columns: []FixedWidthColumn{{Length: 3}, {Length: 5}, {Length: 4}},
+			},
+			input: "001hello2.30\n002world3.40\n",
+			want:  [][]string{{"001", "hello", "2.30"}, {"002", "world", "3.40"}},
+		},
+		{
+			name: "explicit start and padding",
+			configuration: FixedWidthConfiguration{
+				Columns: []FixedWidthColumn{
+					{Start: start(0), Length: 5, Trim: "left", Padding: "0"},
+					{Start: start(8), Length: 6},
+				},
+			},
+			input: "00042XXXabc   \r\n",
+			want:  [][]string{{"42", "abc"}},
+		},
+		{
+			name: "no trimming and short lines",
+			configuration: FixedWidthConfiguration{
+				Trim:    "none",
+				Columns: []FixedWidthColumn{{Length: 4}, {Length: 4}, {Length: 4}},
+			},
+			input: "ab  cd\n\n",
+			want:  [][]string{{"ab  ", "cd", ""}},
+		},
+		{
+			name: "multi byte characters",
+			configuration: FixedWidthConfiguration{
+				Columns: []FixedWidthColumn{{Length: 6}, {Length: 3}},
+			},
+			input: "Müller123",
+			want:  [][]string{{"Müller", "123"}},
+		},
+		{
+			name:          "no columns",
+			configuration: FixedWidthConfiguration{},
+			wantErr:       true,
+		},
+		{
+			name: "invalid length",
+			configuration: FixedWidthConfiguration{
+				Columns: []FixedWidthColumn{{Length: 0}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown trim",
+			configuration: FixedWidthConfiguration{
+				Trim:    "middle",
+				Columns: []FixedWidthColumn{{Length: 1}},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader, err := newFixedWidthReader(strings.NewReader(tt.input), tt.configuration)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newFixedWidthReader() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			var got [][]string
+			for {
+				record, err := reader.read()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("read() error = %v", err)
+				}
+				got = append(got, record)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("read() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestMapFixedWidth tests the Map method reading named fixed width input.
+func TestMapFixedWidth(t *testing.T) {
+	mappingJSON := `{
+		"fixed_width": {
+			"columns": [
+				{"name": "id", "length": 5, "trim": "left", "padding": "0"},
+				{"name": "text", "length": 10},
+				{"name": "value", "length": 6, "trim": "left"}
+			]
+		},
+		"mapping": {
+			"id": {"property": "id", "type": "int"},
+			"text": {"property": "nested.text", "type": "string"},
+			"value": {"property": "value", "type": "float"}
+		},
+		"calculated": [
+			{"property": "record", "kind": "application", "format": "record", "type": "int", "location": "record"}
+		]
+	}`
+
+	tempMappingFile, err := os.CreateTemp("", "mapping_fixed*.json")
+	if err != nil {
+		t.Fatalf("Failed to create temp mapping file: %v", err)
+	}
+	defer os.Remove(tempMappingFile.Name())
+	if _, err := tempMappingFile.Write([]byte(mappingJSON)); err != nil {
+		t.Fatalf("Failed to write to temp mapping file: %v", err)
+	}
+	tempMappingFile.Close()
+
+	input := "00001hello        2.3\n00002big world  13.75\n"
+	tempInputFile, err := os.CreateTemp("", "input_fixed*.txt")
+	if err != nil {
+		t.Fatalf("Failed to create temp input file: %v", err)
+	}
+	defer os.Remove(tempInputFile.Name())
+	if _, err := tempInputFile.Write([]byte(input)); err != nil {
+		t.Fatalf("Failed to write to temp input file: %v", err)
+	}
+	tempInputFile.Close()
+
+	tempOutFile, err := os.CreateTemp("", "output_fixed*.json")
+	if err != nil {
+		t.Fatalf("Failed to create temp output file: %v", err)
+	}
+	defer os.Remove(tempOutFile.Name())
+	tempOutFile.Close()
+
+	mapper, err := NewMapper(
+		WithIn(tempInputFile.Name()),
+		WithOut(tempOutFile.Name()),
+		WithNamed(true),
+		WithArray(true),
+		WithMappingFile(tempMappingFile.Name()),
+		WithOutputType("json"),
+		WithInputType("fixed-width"),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create mapper: %v", err)
+	}
+	if err := mapper.Map(); err != nil {
+		t.Fatalf("Failed to map: %v", err)
+	}
+
+	outputData, err := os.ReadFile(tempOutFile.Name())
+	if err != nil {
+		t.Fatalf("Failed to read output file: %v", err)
+	}
+	var result []map[string]any
+	if err := json.Unmarshal(outputData, &result); err != nil {
+		t.Fatalf("Failed to parse output JSON: %v", err)
+	}
+	want := []map[string]any{
+		{"id": float64(1), "nested": map[string]any{"text": "hello"}, "value": 2.3, "record": float64(0)},
+		{"id": float64(2), "nested": map[string]any{"text": "big world"}, "value": 13.75, "record": float64(1)},
+	}
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("Map() = %v, want %v", result, want)
+	}
+}
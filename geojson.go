@@ -0,0 +1,385 @@
+package csv2json
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// geoJSONWriter writes records as GeoJSON features. The geometry is built from the columns designated as latitude
+// and longitude or as WKT geometry, all other properties become the properties of the feature. As array the
+// features are written as a FeatureCollection, otherwise as newline-delimited features.
+type geoJSONWriter struct {
+	mapper      *Mapper
+	out         io.Writer
+	recordOrder []string
+
+	// latitude, longitude and wkt name the properties holding the geometry
+	latitude, longitude, wkt string
+
+	// encoder writes features of newline-delimited output on a single line
+	encoder jsonEncoder
+
+	written int
+	buf     bytes.Buffer
+}
+
+// newGeoJSONWriter creates a geoJSONWriter for the geometry columns of the mapping.
+func (m *Mapper) newGeoJSONWriter(out io.Writer, recordOrder []string) (*geoJSONWriter, error) {
+	w := &geoJSONWriter{mapper: m, out: out, recordOrder: recordOrder, encoder: m.encoder}
+	if !m.array {
+		w.encoder.indent = 0
+	}
+	mappings := []map[string]ColumnConfiguration{m.configuration.Mapping}
+	for _, recordType := range m.configuration.RecordTypes.Types {
+		if recordType.Location != "document" {
+			mappings = append(mappings, recordType.Mapping)
+		}
+	}
+	for _, mapping := range mappings {
+		for _, column := range mapping {
+			var target *string
+			switch column.Geometry {
+			case "":
+				continue
+			case "latitude":
+				target = &w.latitude
+			case "longitude":
+				target = &w.longitude
+			case "wkt":
+				target = &w.wkt
+			default:
+				return nil, fmt.Errorf("unknown geometry %q of property %q, expected latitude, longitude or wkt", column.Geometry, column.Property)
+			}
+			if *target != "" && *target != column.Property {
+				return nil, fmt.Errorf("%s is provided by both %q and %q", column.Geometry, *target, column.Property)
+			}
+			*target = column.Property
+		}
+	}
+	switch {
+	case w.wkt != "" && (w.latitude != "" || w.longitude != ""):
+		return nil, errors.New("geojson output uses either latitude and longitude or wkt columns")
+	case w.wkt == "" && (w.latitude == "" || w.longitude == ""):
+		return nil, errors.New("geojson output requires a latitude and a longitude or a wkt column")
+	}
+	return w, nil
+}
+
+// write converts the record to a feature and writes it.
+func (w *geoJSONWriter) write(record map[string]any) error {
+	feature, err := w.feature(record)
+	if err != nil {
+		return err
+	}
+	w.buf.Reset()
+	if !w.mapper.array {
+		if w.written > 0 {
+			w.buf.WriteByte('\n')
+		}
+		err = w.encoder.encode(&w.buf, feature, 0)
+	} else {
+		if w.written == 0 {
+			w.start()
+		} else {
+			w.buf.WriteByte(',')
+		}
+		w.encoder.newline(&w.buf, 2)
+		err = w.encoder.encode(&w.buf, feature, 2)
+	}
+	if err != nil {
+		return err
+	}
+	w.written++
+	_, err = w.out.Write(w.buf.Bytes())
+	return err
+}
+
+// start writes the opening of the FeatureCollection.
+func (w *geoJSONWriter) start() {
+	w.buf.WriteByte('{')
+	w.encoder.newline(&w.buf, 1)
+	w.encoder.encodeString(&w.buf, "type")
+	w.colon()
+	w.encoder.encodeString(&w.buf, "FeatureCollection")
+	w.buf.WriteByte(',')
+	w.encoder.newline(&w.buf, 1)
+	w.encoder.encodeString(&w.buf, "features")
+	w.colon()
+	w.buf.WriteByte('[')
+}
+
+// colon writes the separator between a key and its value.
+func (w *geoJSONWriter) colon() {
+	w.buf.WriteByte(':')
+	if w.encoder.indent > 0 {
+		w.buf.WriteByte(' ')
+	}
+}
+
+// close ends the FeatureCollection.
+func (w *geoJSONWriter) close(map[string]any) error {
+	if !w.mapper.array {
+		return nil
+	}
+	w.buf.Reset()
+	if w.written == 0 {
+		w.start()
+	} else {
+		w.encoder.newline(&w.buf, 1)
+	}
+	w.buf.WriteByte(']')
+	w.encoder.newline(&w.buf, 0)
+	w.buf.WriteByte('}')
+	_, err := w.out.Write(w.buf.Bytes())
+	return err
+}
+
+// feature removes the geometry properties from the record and returns the feature.
+func (w *geoJSONWriter) feature(record map[string]any) (orderedMap, error) {
+	var geometry any
+	var err error
+	if w.wkt != "" {
+		value := removeValue(strings.Split(w.wkt, "."), record)
+		if value != nil && value != "" {
+			text, ok := value.(string)
+			if !ok {
+				return orderedMap{}, fmt.Errorf("wkt property %q must be a string, found %T", w.wkt, value)
+			}
+			geometry, err = parseWKT(text)
+		}
+	} else {
+		latitude := removeValue(strings.Split(w.latitude, "."), record)
+		longitude := removeValue(strings.Split(w.longitude, "."), record)
+		geometry, err = pointGeometry(latitude, longitude)
+	}
+	if err != nil {
+		return orderedMap{}, err
+	}
+	return orderedMap{
+		keys: []string{"type", "geometry", "properties"},
+		values: map[string]any{
+			"type":       "Feature",
+			"geometry":   geometry,
+			"properties": w.mapper.order(record, w.recordOrder),
+		},
+	}, nil
+}
+
+// removeValue removes the value stored at the hierarchy of keys and returns it. Objects left empty are removed.
+func removeValue(hierarchy []string, data map[string]any) any {
+	if len(hierarchy) == 1 {
+		value := data[hierarchy[0]]
+		delete(data, hierarchy[0])
+		return value
+	}
+	inside, ok := data[hierarchy[0]].(map[string]any)
+	if !ok {
+		return nil
+	}
+	value := removeValue(hierarchy[1:], inside)
+	if len(inside) == 0 {
+		delete(data, hierarchy[0])
+	}
+	return value
+}
+
+// pointGeometry returns a Point for latitude and longitude, nil if both are missing.
+func pointGeometry(latitude, longitude any) (any, error) {
+	if (latitude == nil || latitude == "") && (longitude == nil || longitude == "") {
+		return nil, nil
+	}
+	lat, err := coordinate("latitude", latitude)
+	if err != nil {
+		return nil, err
+	}
+	lon, err := coordinate("longitude", longitude)
+	if err != nil {
+		return nil, err
+	}
+	if err = validatePosition(lon, lat); err != nil {
+		return nil, err
+	}
+	return geometryObject("Point", []any{lon, lat}), nil
+}
+
+// coordinate converts a latitude or longitude value to a float.
+func coordinate(name string, value any) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case int:
+		return float64(v), nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return 0, fmt.Errorf("%s %q is not a number", name, v)
+		}
+		return f, nil
+	case nil:
+		return 0, fmt.Errorf("%s is missing", name)
+	}
+	return 0, fmt.Errorf("%s must be a number, found %T", name, value)
+}
+
+// validatePosition checks the ranges of longitude and latitude.
+func validatePosition(longitude, latitude float64) error {
+	if latitude < -90 || latitude > 90 {
+		return fmt.Errorf("latitude %v out of range [-90, 90]", latitude)
+	}
+	if longitude < -180 || longitude > 180 {
+		return fmt.Errorf("longitude %v out of range [-180, 180]", longitude)
+	}
+	return nil
+}
+
+// geometryObject returns a geometry with type and coordinates.
+func geometryObject(geometryType string, coordinates any) orderedMap {
+	return orderedMap{
+		keys:   []string{"type", "coordinates"},
+		values: map[string]any{"type": geometryType, "coordinates": coordinates},
+	}
+}
+
+// wktGeometries maps WKT geometry names to the GeoJSON type and the nesting depth of their coordinates
+var wktGeometries = map[string]struct {
+	name  string
+	depth int
+}{
+	"POINT":           {"Point", 0},
+	"LINESTRING":      {"LineString", 1},
+	"MULTIPOINT":      {"MultiPoint", 1},
+	"POLYGON":         {"Polygon", 2},
+	"MULTILINESTRING": {"MultiLineString", 2},
+	"MULTIPOLYGON":    {"MultiPolygon", 3},
+}
+
+// parseWKT converts a well-known text geometry to a GeoJSON geometry. EMPTY geometries are returned as nil.
+func parseWKT(text string) (any, error) {
+	p := &wktParser{text: text}
+	name := strings.ToUpper(p.word())
+	geometry, ok := wktGeometries[name]
+	if !ok {
+		return nil, fmt.Errorf("unsupported WKT geometry %q", text)
+	}
+	dimension := strings.ToUpper(p.word())
+	if dimension == "Z" {
+		dimension = strings.ToUpper(p.word())
+	}
+	if dimension == "EMPTY" {
+		return nil, nil
+	}
+	if dimension != "" {
+		return nil, fmt.Errorf("invalid WKT geometry %q", text)
+	}
+	if p.skipSpace() == len(p.text) || p.text[p.pos] != '(' {
+		return nil, fmt.Errorf("invalid WKT geometry %q: missing (", text)
+	}
+	coordinates, err := p.coordinates(geometry.depth)
+	if err == nil && p.skipSpace() < len(p.text) {
+		err = errors.New("unexpected text after geometry")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid WKT geometry %q: %w", text, err)
+	}
+	return geometryObject(geometry.name, coordinates), nil
+}
+
+// wktParser reads well-known text
+type wktParser struct {
+	text string
+	pos  int
+}
+
+// skipSpace skips white space and returns the position of the next character.
+func (p *wktParser) skipSpace() int {
+	for p.pos < len(p.text) && unicode.IsSpace(rune(p.text[p.pos])) {
+		p.pos++
+	}
+	return p.pos
+}
+
+// word reads a word of letters.
+func (p *wktParser) word() string {
+	start := p.skipSpace()
+	for p.pos < len(p.text) && unicode.IsLetter(rune(p.text[p.pos])) {
+		p.pos++
+	}
+	return p.text[start:p.pos]
+}
+
+// consume reads the character c if it is the next one.
+func (p *wktParser) consume(c byte) bool {
+	if p.skipSpace() < len(p.text) && p.text[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// coordinates reads a parenthesized list of the given depth, positions have depth 0.
+func (p *wktParser) coordinates(depth int) (any, error) {
+	if depth == 0 {
+		// positions of a MULTIPOINT may be parenthesized
+		if p.consume('(') {
+			position, err := p.position()
+			if err == nil && !p.consume(')') {
+				err = errors.New("missing )")
+			}
+			return position, err
+		}
+		return p.position()
+	}
+	if !p.consume('(') {
+		return nil, errors.New("missing (")
+	}
+	var list []any
+	for {
+		value, err := p.coordinates(depth - 1)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, value)
+		if p.consume(',') {
+			continue
+		}
+		if !p.consume(')') {
+			return nil, errors.New("missing )")
+		}
+		return list, nil
+	}
+}
+
+// position reads two or three numbers separated by white space.
+func (p *wktParser) position() ([]any, error) {
+	var numbers []float64
+	for len(numbers) < 3 {
+		start := p.skipSpace()
+		for p.pos < len(p.text) && strings.IndexByte("+-.0123456789eE", p.text[p.pos]) >= 0 {
+			p.pos++
+		}
+		if start == p.pos {
+			break
+		}
+		f, err := strconv.ParseFloat(p.text[start:p.pos], 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", p.text[start:p.pos])
+		}
+		numbers = append(numbers, f)
+	}
+	if len(numbers) < 2 {
+		return nil, errors.New("a position requires two numbers")
+	}
+	if err := validatePosition(numbers[0], numbers[1]); err != nil {
+		return nil, err
+	}
+	position := make([]any, len(numbers))
+	for i, n := range numbers {
+		position[i] = n
+	}
+	return position, nil
+}
@@ -0,0 +1,203 @@
+package csv2json
+
+import (
+	"testing"
+)
+
+// TestMapGeoJSONOutput tests writing records as features with point geometries.
+func TestMapGeoJSONOutput(t *testing.T) {
+	mappingJSON := `{
+		"mapping": {
+			"0": {"property": "name", "type": "string"},
+			"1": {"property": "location.lat", "type": "float", "geometry": "latitude"},
+			"2": {"property": "location.lon", "type": "float", "geometry": "longitude"}
+		}
+	}`
+
+	tests := []struct {
+		name    string
+		input   string
+		options []OptionFunc
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "feature collection",
+			input:   "Berlin,52.52,13.405\nSydney,-33.87,151.21\n",
+			options: []OptionFunc{WithArray(true)},
+			want: `{"type":"FeatureCollection","features":[` +
+				`{"type":"Feature","geometry":{"type":"Point","coordinates":[13.405,52.52]},"properties":{"name":"Berlin"}},` +
+				`{"type":"Feature","geometry":{"type":"Point","coordinates":[151.21,-33.87]},"properties":{"name":"Sydney"}}]}`,
+		},
+		{
+			name:    "indented feature collection",
+			input:   "Berlin,52.52,13.405\n",
+			options: []OptionFunc{WithArray(true), WithIndent(2)},
+			want: `{
+  "type": "FeatureCollection",
+  "features": [
+    {
+      "type": "Feature",
+      "geometry": {
+        "type": "Point",
+        "coordinates": [
+          13.405,
+          52.52
+        ]
+      },
+      "properties": {
+        "name": "Berlin"
+      }
+    }
+  ]
+}`,
+		},
+		{
+			name:    "empty feature collection",
+			options: []OptionFunc{WithArray(true)},
+			want:    `{"type":"FeatureCollection","features":[]}`,
+		},
+		{
+			name:    "newline-delimited features",
+			input:   "Berlin,52.52,13.405\nSydney,-33.87,151.21\n",
+			options: []OptionFunc{WithIndent(2)},
+			want: `{"type":"Feature","geometry":{"type":"Point","coordinates":[13.405,52.52]},"properties":{"name":"Berlin"}}` + "\n" +
+				`{"type":"Feature","geometry":{"type":"Point","coordinates":[151.21,-33.87]},"properties":{"name":"Sydney"}}`,
+		},
+		{
+			name:    "latitude out of range",
+			input:   "Berlin,91,13.405\n",
+			wantErr: true,
+		},
+		{
+			name:    "longitude out of range",
+			input:   "Berlin,52.52,-180.5\n",
+			wantErr: true,
+		},
+		{
+			name:    "missing longitude",
+			input:   "Berlin,52.52,\n",
+			wantErr: true,
+		},
+		{
+			name:    "nested property",
+			options: []OptionFunc{WithNestedPropertyName("features")},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := mapGeoJSON(t, mappingJSON, tt.input, tt.options...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Map() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("Map() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestMapGeoJSONWKT tests converting WKT columns to geometries.
+func TestMapGeoJSONWKT(t *testing.T) {
+	mappingJSON := `{
+		"mapping": {
+			"0": {"property": "name", "type": "string"},
+			"1": {"property": "shape", "type": "string", "geometry": "wkt"}
+		}
+	}`
+
+	tests := []struct {
+		name    string
+		wkt     string
+		want    string
+		wantErr bool
+	}{
+		{name: "point", wkt: "POINT (30 10)", want: `{"type":"Point","coordinates":[30,10]}`},
+		{name: "point z", wkt: "point z (30 10 5)", want: `{"type":"Point","coordinates":[30,10,5]}`},
+		{name: "linestring", wkt: "LINESTRING (30 10, 10 30, 40 40)", want: `{"type":"LineString","coordinates":[[30,10],[10,30],[40,40]]}`},
+		{name: "polygon", wkt: "POLYGON ((35 10, 45 45, 15 40, 35 10), (20 30, 35 35, 20 30))", want: `{"type":"Polygon","coordinates":[[[35,10],[45,45],[15,40],[35,10]],[[20,30],[35,35],[20,30]]]}`},
+		{name: "multipoint", wkt: "MULTIPOINT ((10 40), (40 30))", want: `{"type":"MultiPoint","coordinates":[[10,40],[40,30]]}`},
+		{name: "multipoint without parentheses", wkt: "MULTIPOINT (10 40, 40 30)", want: `{"type":"MultiPoint","coordinates":[[10,40],[40,30]]}`},
+		{name: "multilinestring", wkt: "MULTILINESTRING ((10 10, 20 20), (40 40, 30 30))", want: `{"type":"MultiLineString","coordinates":[[[10,10],[20,20]],[[40,40],[30,30]]]}`},
+		{name: "multipolygon", wkt: "MULTIPOLYGON (((30 20, 45 40, 30 20)), ((15 5, 40 10, 15 5)))", want: `{"type":"MultiPolygon","coordinates":[[[[30,20],[45,40],[30,20]]],[[[15,5],[40,10],[15,5]]]]}`},
+		{name: "empty", wkt: "POINT EMPTY", want: `null`},
+		{name: "missing", wkt: "", want: `null`},
+		{name: "unsupported", wkt: "CIRCLE (1 2)", wantErr: true},
+		{name: "unbalanced", wkt: "LINESTRING (30 10, 10 30", wantErr: true},
+		{name: "trailing text", wkt: "POINT (30 10) x", wantErr: true},
+		{name: "out of range", wkt: "POINT (30 100)", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := mapGeoJSON(t, mappingJSON, "a,\""+tt.wkt+"\"\n")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Map() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			want := `{"type":"Feature","geometry":` + tt.want + `,"properties":{"name":"a"}}`
+			if !tt.wantErr && got != want {
+				t.Errorf("Map() = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+// TestGeoJSONGeometryColumns tests the validation of the geometry columns of the mapping.
+func TestGeoJSONGeometryColumns(t *testing.T) {
+	tests := []struct {
+		name    string
+		mapping string
+	}{
+		{name: "no geometry", mapping: `{"mapping": {"0": {"property": "name", "type": "string"}}}`},
+		{name: "latitude only", mapping: `{"mapping": {"0": {"property": "lat", "type": "float", "geometry": "latitude"}}}`},
+		{name: "unknown geometry", mapping: `{"mapping": {"0": {"property": "x", "type": "float", "geometry": "x"}}}`},
+		{name: "two latitudes", mapping: `{"mapping": {"0": {"property": "a", "type": "float", "geometry": "latitude"}, "1": {"property": "b", "type": "float", "geometry": "latitude"}, "2": {"property": "c", "type": "float", "geometry": "longitude"}}}`},
+		{name: "point and wkt", mapping: `{"mapping": {"0": {"property": "a", "type": "float", "geometry": "latitude"}, "1": {"property": "b", "type": "float", "geometry": "longitude"}, "2": {"property": "c", "type": "string", "geometry": "wkt"}}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := mapGeoJSON(t, tt.mapping, "1,2,3\n"); err == nil {
+				t.Error("Map() expected error")
+			}
+		})
+	}
+}
+
+// mapGeoJSON maps input with GeoJSON output and returns the output.
+func mapGeoJSON(t *testing.T, mappingJSON, input string, options ...OptionFunc) (string, error) {
+	t.Helper()
+	data, err := mapToFile(t, mappingJSON, input, append([]OptionFunc{WithOutputType("geojson")}, options...)...)
+	return string(data), err
+}
+
+// TestPointGeometry tests building points from numbers and text.
+func TestPointGeometry(t *testing.T) {
+	tests := []struct {
+		name                string
+		latitude, longitude any
+		wantNil             bool
+		wantErr             bool
+	}{
+		{name: "floats", latitude: 52.52, longitude: 13.405},
+		{name: "ints", latitude: 52, longitude: 13},
+		{name: "text", latitude: " 52.52", longitude: "13.405"},
+		{name: "missing", latitude: "", longitude: nil, wantNil: true},
+		{name: "no number", latitude: "north", longitude: "13.405", wantErr: true},
+		{name: "bool", latitude: true, longitude: 13.405, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := pointGeometry(tt.latitude, tt.longitude)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("pointGeometry() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (got == nil) != tt.wantNil {
+				t.Errorf("pointGeometry() = %v, wantNil %v", got, tt.wantNil)
+			}
+		})
+	}
+}
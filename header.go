@@ -0,0 +1,80 @@
+package csv2json
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// headerNormalizationRules lists the supported header normalization rules in the order they are applied.
+var headerNormalizationRules = []string{"strip_punctuation", "case_fold", "collapse_whitespace", "remove_whitespace", "trim"}
+
+// prepareColumns validates the header normalization rules and builds the lookup of normalized
+// mapping keys and aliases. Two columns resolving to the same normalized name are an error.
+func (m *Mapper) prepareColumns() error {
+	for _, rule := range m.configuration.HeaderNormalization {
+		if !slices.Contains(headerNormalizationRules, rule) {
+			return fmt.Errorf("unknown header normalization rule %q", rule)
+		}
+	}
+	m.columnKeys = make(map[string]string)
+	for key, column := range m.configuration.Mapping {
+		for _, name := range append([]string{key}, column.Aliases...) {
+			normalized := m.normalizeHeader(name)
+			if existing, ok := m.columnKeys[normalized]; ok && existing != key {
+				return fmt.Errorf("header name %q of mapping %q is ambiguous, it also resolves to mapping %q", name, key, existing)
+			}
+			m.columnKeys[normalized] = key
+		}
+	}
+	return nil
+}
+
+// normalizeHeader applies the configured normalization rules to a header name.
+func (m *Mapper) normalizeHeader(name string) string {
+	for _, rule := range headerNormalizationRules {
+		if !slices.Contains(m.configuration.HeaderNormalization, rule) {
+			continue
+		}
+		switch rule {
+		case "strip_punctuation":
+			name = strings.Map(func(r rune) rune {
+				if unicode.IsPunct(r) {
+					return -1
+				}
+				return r
+			}, name)
+		case "case_fold":
+			name = strings.ToLower(name)
+		case "collapse_whitespace":
+			name = strings.Join(strings.Fields(name), " ")
+		case "remove_whitespace":
+			name = strings.Join(strings.Fields(name), "")
+		case "trim":
+			name = strings.TrimSpace(name)
+		}
+	}
+	return name
+}
+
+// columnKey resolves a header name to its mapping key. Names not resolving to a mapping are returned normalized.
+func (m *Mapper) columnKey(name string) string {
+	normalized := m.normalizeHeader(name)
+	if key, ok := m.columnKeys[normalized]; ok {
+		return key
+	}
+	return normalized
+}
+
+// headerKeys resolves all names of a header to mapping keys.
+func (m *Mapper) headerKeys(header []string) []string {
+	if header == nil {
+		return nil
+	}
+	keys := make([]string, len(header))
+	for i, name := range header {
+		keys[i] = m.columnKey(name)
+	}
+	return keys
+}
@@ -0,0 +1,186 @@
+package csv2json
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestNormalizeHeader tests the header normalization rules.
+func TestNormalizeHeader(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []string
+		in    string
+		want  string
+	}{
+		{
+			name: "no rules",
+			in:   " Customer ID ",
+			want: " Customer ID ",
+		},
+		{
+			name:  "trim and case fold",
+			rules: []string{"trim", "case_fold"},
+			in:    " Customer ID ",
+			want:  "customer id",
+		},
+		{
+			name:  "collapse whitespace",
+			rules: []string{"collapse_whitespace"},
+			in:    "  Customer \t  ID ",
+			want:  "Customer ID",
+		},
+		{
+			name:  "strip punctuation and remove whitespace",
+			rules: []string{"case_fold", "strip_punctuation", "remove_whitespace"},
+			in:    "Customer_ID (new)",
+			want:  "customeridnew",
+		},
+		{
+			name:  "rules are applied in a fixed order",
+			rules: []string{"trim", "strip_punctuation"},
+			in:    " _id_ ",
+			want:  "id",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Mapper{configuration: Configuration{HeaderNormalization: tt.rules}}
+			if got := m.normalizeHeader(tt.in); got != tt.want {
+				t.Errorf("normalizeHeader() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestPrepareColumns tests resolving header names through normalization and aliases.
+func TestPrepareColumns(t *testing.T) {
+	tests := []struct {
+		name          string
+		configuration Configuration
+		header        []string
+		want          []string
+		wantErr       bool
+	}{
+		{
+			name: "aliases",
+			configuration: Configuration{
+				Mapping: map[string]ColumnConfiguration{
+					"customer_id": {Property: "customer", Aliases: []string{"Customer ID", "customerId"}},
+				},
+			},
+			header: []string{"customerId", "Customer ID", "customer_id", "other"},
+			want:   []string{"customer_id", "customer_id", "customer_id", "other"},
+		},
+		{
+			name: "normalized aliases",
+			configuration: Configuration{
+				HeaderNormalization: []string{"trim", "case_fold", "strip_punctuation", "remove_whitespace"},
+				Mapping: map[string]ColumnConfiguration{
+					"customer_id": {Property: "customer"},
+				},
+			},
+			header: []string{" customerId ", "Customer ID", "CUSTOMER_ID", "Other Column"},
+			want:   []string{"customer_id", "customer_id", "customer_id", "othercolumn"},
+		},
+		{
+			name: "ambiguous columns",
+			configuration: Configuration{
+				HeaderNormalization: []string{"case_fold"},
+				Mapping: map[string]ColumnConfiguration{
+					"id": {Property: "a"},
+					"ID": {Property: "b"},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown rule",
+			configuration: Configuration{
+				HeaderNormalization: []string{"upper"},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Mapper{configuration: tt.configuration}
+			err := m.prepareColumns()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("prepareColumns() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := m.headerKeys(tt.header); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("headerKeys() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestMapWithHeaderAliases tests the Map method on inputs using different spellings of the same columns.
+func TestMapWithHeaderAliases(t *testing.T) {
+	dir := t.TempDir()
+	mappingFile := filepath.Join(dir, "mapping.json")
+	mappingJSON := `{
+		"header_normalization": ["trim", "case_fold", "collapse_whitespace"],
+		"mapping": {
+			"customer id": {"property": "customer", "type": "int", "aliases": ["customer_id", "customerid"]},
+			"status": {"property": "status", "type": "string"}
+		},
+		"calculated": [
+			{"property": "active", "kind": "mapping", "format": "Status:a=true,default=false", "type": "bool", "location": "record"}
+		]
+	}`
+	if err := os.WriteFile(mappingFile, []byte(mappingJSON), 0600); err != nil {
+		t.Fatalf("Failed to write mapping file: %v", err)
+	}
+	inputs := map[string]string{
+		"1.csv": "Customer ID,Status\n1,a\n",
+		"2.csv": "customer_id,STATUS\n2,b\n",
+		"3.csv": " customerId ,status \n3,a\n",
+	}
+	for name, content := range inputs {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatalf("Failed to write input file: %v", err)
+		}
+	}
+	out := filepath.Join(dir, "out.json")
+
+	mapper, err := NewMapper(
+		WithIn(filepath.Join(dir, "*.csv")),
+		WithOut(out),
+		WithNamed(true),
+		WithArray(true),
+		WithMappingFile(mappingFile),
+		WithOutputType("json"),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create mapper: %v", err)
+	}
+	if err := mapper.Map(); err != nil {
+		t.Fatalf("Failed to map: %v", err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("Failed to read output file: %v", err)
+	}
+	var result []map[string]any
+	if err := json.Unmarshal(data, &result); err != nil {
+		t.Fatalf("Failed to parse output JSON: %v", err)
+	}
+	want := []map[string]any{
+		{"customer": float64(1), "status": "a", "active": true},
+		{"customer": float64(2), "status": "b", "active": false},
+		{"customer": float64(3), "status": "a", "active": true},
+	}
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("Map() = %v, want %v", result, want)
+	}
+}
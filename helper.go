@@ -39,6 +39,19 @@ func setValue(hierarchy []string, value any, data map[string]interface{}) map[st
 	return data
 }
 
+// getValue returns the value stored at the hierarchy of keys and whether it exists.
+func getValue(hierarchy []string, data map[string]any) (any, bool) {
+	val, ok := data[hierarchy[0]]
+	if !ok || len(hierarchy) == 1 {
+		return val, ok
+	}
+	inside, ok := val.(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	return getValue(hierarchy[1:], inside)
+}
+
 // setValueInternal recursively creates and maps nested dictionaries based on a hierarchy of keys, assigning a final value.
 func setValueInternal(hierarchy []string, value any, inside map[string]any) any {
 	if len(hierarchy) == 1 {
@@ -1,6 +1,8 @@
 package csv2json
 
 import (
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 )
@@ -62,3 +64,86 @@ func TestSetValue(t *testing.T) {
 		})
 	}
 }
+
+// TestGetValue tests the getValue function which looks up values in nested dictionaries.
+func TestGetValue(t *testing.T) {
+	data := map[string]any{
+		"parent": map[string]any{
+			"child": "value",
+		},
+		"leaf": 1,
+	}
+
+	tests := []struct {
+		name      string
+		hierarchy []string
+		want      any
+		wantFound bool
+	}{
+		{
+			name:      "top level",
+			hierarchy: []string{"leaf"},
+			want:      1,
+			wantFound: true,
+		},
+		{
+			name:      "nested",
+			hierarchy: []string{"parent", "child"},
+			want:      "value",
+			wantFound: true,
+		},
+		{
+			name:      "missing",
+			hierarchy: []string{"parent", "other"},
+		},
+		{
+			name:      "below a leaf",
+			hierarchy: []string{"leaf", "child"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := getValue(tt.hierarchy, data)
+			if found != tt.wantFound || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getValue() = %v, %v, want %v, %v", got, found, tt.want, tt.wantFound)
+			}
+		})
+	}
+}
+
+// mapFiles writes the mapping and the input to a temporary directory, maps the input to an output file in the
+// directory using the options and returns the path of the output file.
+func mapFiles(t *testing.T, mappingJSON, input string, options ...OptionFunc) (string, error) {
+	t.Helper()
+	dir := t.TempDir()
+	mappingFile := filepath.Join(dir, "mapping.json")
+	if err := os.WriteFile(mappingFile, []byte(mappingJSON), 0600); err != nil {
+		t.Fatalf("Failed to write mapping file: %v", err)
+	}
+	in := filepath.Join(dir, "input.csv")
+	if err := os.WriteFile(in, []byte(input), 0600); err != nil {
+		t.Fatalf("Failed to write input file: %v", err)
+	}
+	out := filepath.Join(dir, "out")
+
+	mapper, err := NewMapper(append([]OptionFunc{WithIn(in), WithOut(out), WithMappingFile(mappingFile)}, options...)...)
+	if err == nil {
+		err = mapper.Map()
+	}
+	return out, err
+}
+
+// mapToFile maps the input like mapFiles and returns the output.
+func mapToFile(t *testing.T, mappingJSON, input string, options ...OptionFunc) ([]byte, error) {
+	t.Helper()
+	out, err := mapFiles(t, mappingJSON, input, options...)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("Failed to read output file: %v", err)
+	}
+	return data, nil
+}
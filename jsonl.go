@@ -0,0 +1,171 @@
+package csv2json
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// jsonReader reads records from JSON Lines input or a JSON array of objects. Nested objects are flattened
+// using dotted keys, the keys of the first object in the order they appear become the columns of all records.
+type jsonReader struct {
+	decoder *json.Decoder
+
+	// array is set if the input is a single JSON array instead of JSON Lines
+	array bool
+
+	// state is one of start, records or done for array input
+	state string
+
+	// header holds the columns determined from the first object
+	header []string
+
+	// pending holds the first record if it was read to determine the header
+	pending []string
+
+	// row counts the objects read, used in error messages
+	row int
+}
+
+// newJSONReader creates a jsonReader, array denotes a JSON array as input.
+func newJSONReader(in io.Reader, array bool) *jsonReader {
+	decoder := json.NewDecoder(in)
+	decoder.UseNumber()
+	return &jsonReader{decoder: decoder, array: array, state: "start"}
+}
+
+// readHeader reads the first object and returns its flattened keys.
+func (j *jsonReader) readHeader() ([]string, error) {
+	keys, values, err := j.next()
+	if err != nil {
+		if err == io.EOF {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	j.header = keys
+	j.pending = j.record(values)
+	return j.header, nil
+}
+
+// read returns the values of the next object in the order of the header. Keys missing in the object
+// yield an empty value, keys not part of the header are ignored.
+func (j *jsonReader) read() ([]string, error) {
+	if j.pending != nil {
+		record := j.pending
+		j.pending = nil
+		return record, nil
+	}
+	keys, values, err := j.next()
+	if err != nil {
+		return nil, err
+	}
+	if j.header == nil {
+		j.header = keys
+	}
+	return j.record(values), nil
+}
+
+// record orders the values by the header.
+func (j *jsonReader) record(values map[string]string) []string {
+	record := make([]string, len(j.header))
+	for i, key := range j.header {
+		record[i] = values[key]
+	}
+	return record
+}
+
+// next decodes the next object and returns its flattened keys and values.
+func (j *jsonReader) next() ([]string, map[string]string, error) {
+	if j.array {
+		switch j.state {
+		case "start":
+			token, err := j.decoder.Token()
+			if err != nil {
+				return nil, nil, err
+			}
+			if token != json.Delim('[') {
+				return nil, nil, fmt.Errorf("expected a JSON array as input, found %v", token)
+			}
+			j.state = "records"
+		case "done":
+			return nil, nil, io.EOF
+		}
+		if !j.decoder.More() {
+			// consume the closing bracket
+			if _, err := j.decoder.Token(); err != nil {
+				return nil, nil, err
+			}
+			j.state = "done"
+			return nil, nil, io.EOF
+		}
+	}
+	var raw json.RawMessage
+	if err := j.decoder.Decode(&raw); err != nil {
+		return nil, nil, err
+	}
+	j.row++
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || raw[0] != '{' {
+		return nil, nil, fmt.Errorf("record %d is not a JSON object", j.row)
+	}
+	var keys []string
+	values := make(map[string]string)
+	if err := flattenJSON(raw, "", &keys, values); err != nil {
+		return nil, nil, fmt.Errorf("record %d: %w", j.row, err)
+	}
+	return keys, values, nil
+}
+
+// flattenJSON adds the values of a JSON object to values using dotted keys for nested objects. The keys are
+// appended to keys in the order they appear. Arrays are kept as JSON text, null becomes an empty value.
+func flattenJSON(raw json.RawMessage, prefix string, keys *[]string, values map[string]string) error {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	// opening brace
+	if _, err := decoder.Token(); err != nil {
+		return err
+	}
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		key := prefix + token.(string)
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return err
+		}
+		value = bytes.TrimSpace(value)
+		switch value[0] {
+		case '{':
+			if err := flattenJSON(value, key+".", keys, values); err != nil {
+				return err
+			}
+			continue
+		case '"':
+			var s string
+			if err := json.Unmarshal(value, &s); err != nil {
+				return err
+			}
+			values[key] = s
+		case '[':
+			var compact bytes.Buffer
+			if err := json.Compact(&compact, value); err != nil {
+				return err
+			}
+			values[key] = compact.String()
+		case 'n':
+			values[key] = ""
+		default:
+			// numbers and booleans keep their literal representation
+			values[key] = strings.TrimSpace(string(value))
+		}
+		if !slices.Contains(*keys, key) {
+			*keys = append(*keys, key)
+		}
+	}
+	return nil
+}
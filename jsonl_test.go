@@ -0,0 +1,120 @@
+package csv2json
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestJSONReader tests reading flattened records from JSON Lines and JSON array input.
+func TestJSONReader(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		array      bool
+		named      bool
+		wantHeader []string
+		want       [][]string
+		wantErr    bool
+	}{
+		{
+			name:       "json lines with nested objects",
+			input:      `{"id":1,"user":{"name":"Jane","active":true},"tags":["a", "b"]}` + "\n" + `{"id":2,"user":{"name":"Joe"},"note":null,"extra":"ignored"}`,
+			named:      true,
+			wantHeader: []string{"id", "user.name", "user.active", "tags"},
+			want:       [][]string{{"1", "Jane", "true", `["a","b"]`}, {"2", "Joe", "", ""}},
+		},
+		{
+			name:  "json array by index",
+			input: `[ {"b": "x", "a": 1.50}, {"a": 2, "b": "y"} ]`,
+			array: true,
+			want:  [][]string{{"x", "1.50"}, {"y", "2"}},
+		},
+		{
+			name:  "pretty printed json lines",
+			input: "{\n  \"id\": 1\n}\n{\n  \"id\": 2\n}\n",
+			want:  [][]string{{"1"}, {"2"}},
+		},
+		{
+			name:    "not an object",
+			input:   `[1, 2]`,
+			array:   true,
+			wantErr: true,
+		},
+		{
+			name:    "not an array",
+			input:   `{"id": 1}`,
+			array:   true,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := newJSONReader(strings.NewReader(tt.input), tt.array)
+			var (
+				got [][]string
+				err error
+			)
+			if tt.named {
+				var header []string
+				header, err = reader.readHeader()
+				if err == nil && !reflect.DeepEqual(header, tt.wantHeader) {
+					t.Errorf("readHeader() = %q, want %q", header, tt.wantHeader)
+				}
+			}
+			for err == nil {
+				var record []string
+				record, err = reader.read()
+				if err == nil {
+					got = append(got, record)
+				}
+			}
+			if (err != io.EOF) != tt.wantErr {
+				t.Fatalf("read() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("read() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestMapJSONLinesInput tests reshaping JSON Lines input with the mapping.
+func TestMapJSONLinesInput(t *testing.T) {
+	mappingJSON := `{
+		"mapping": {
+			"id": {"property": "identifier", "type": "int"},
+			"user.name": {"property": "name", "type": "string"},
+			"user.score": {"property": "stats.score", "type": "float"}
+		},
+		"calculated": [
+			{"property": "level", "kind": "mapping", "format": "user.level:1=low,2=high,default=unknown", "type": "string", "location": "record"}
+		]
+	}`
+	input := `{"id": 1, "user": {"name": "Jane", "score": 2.5, "level": 2}}
+{"id": 2, "user": {"name": "Joe", "score": 1, "level": 1}}
+`
+	data, err := mapToFile(t, mappingJSON, input,
+		WithNamed(true),
+		WithArray(true),
+		WithOutputType("json"),
+		WithInputType("jsonl"),
+	)
+	if err != nil {
+		t.Fatalf("Failed to map: %v", err)
+	}
+	var result []map[string]any
+	if err := json.Unmarshal(data, &result); err != nil {
+		t.Fatalf("Failed to parse output JSON: %v", err)
+	}
+	want := []map[string]any{
+		{"identifier": float64(1), "name": "Jane", "stats": map[string]any{"score": 2.5}, "level": "high"},
+		{"identifier": float64(2), "name": "Joe", "stats": map[string]any{"score": float64(1)}, "level": "low"},
+	}
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("Map() = %v, want %v", result, want)
+	}
+}
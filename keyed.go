@@ -0,0 +1,82 @@
+package csv2json
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// keyedWriter collects all records in an object keyed by the values of the key properties and writes it as
+// document when it is closed. Records with the same key are handled according to the duplicate key policy.
+type keyedWriter struct {
+	mapper        *Mapper
+	out           io.Writer
+	recordOrder   []string
+	documentOrder []string
+
+	// keys holds the keys in the order they were first seen
+	keys   []string
+	values map[string]any
+}
+
+// newKeyedWriter creates a keyedWriter. Key properties are checked per record, as records of named columns
+// without mapping have no declared properties.
+func (m *Mapper) newKeyedWriter(out io.Writer, recordOrder, documentOrder []string) *keyedWriter {
+	return &keyedWriter{mapper: m, out: out, recordOrder: recordOrder, documentOrder: documentOrder, values: make(map[string]any)}
+}
+
+// write adds the record to the object under its key. With the array policy every key holds an array of records.
+func (w *keyedWriter) write(record map[string]any) error {
+	values := make(map[string]any)
+	flattenRecord(record, "", values)
+	parts := make([]string, len(w.mapper.keyBy))
+	for i, property := range w.mapper.keyBy {
+		if values[property] == nil {
+			return fmt.Errorf("record has no value for key property %q", property)
+		}
+		text, _, err := w.mapper.cellText(values[property])
+		if err != nil {
+			return err
+		}
+		parts[i] = text
+	}
+	key := strings.Join(parts, w.mapper.keySeparator)
+	existing, ok := w.values[key]
+	if !ok {
+		w.keys = append(w.keys, key)
+	}
+	switch {
+	case w.mapper.duplicateKeys == "array":
+		// every key holds an array, so all values have the same shape
+		list, _ := existing.([]any)
+		w.values[key] = append(list, record)
+	case !ok || w.mapper.duplicateKeys == "last":
+		w.values[key] = record
+	case w.mapper.duplicateKeys == "error":
+		return fmt.Errorf("duplicate key %q", key)
+	}
+	return nil
+}
+
+// close marshals the keyed object, as document or as document property.
+func (w *keyedWriter) close(document map[string]any) error {
+	values := make(map[string]any, len(w.values))
+	for key, value := range w.values {
+		values[key] = w.mapper.order(value, w.recordOrder)
+	}
+	var keyed any = values
+	if w.mapper.ordered || len(w.mapper.configuration.Order) > 0 {
+		// keys keep the order of the input
+		keyed = orderedMap{keys: w.keys, values: values}
+	}
+	if property := w.mapper.documentProperty(); property != "" {
+		document[property] = keyed
+		keyed = w.mapper.order(document, w.documentOrder)
+	}
+	d, err := w.mapper.marshaler(keyed)
+	if err != nil {
+		return err
+	}
+	_, err = w.out.Write(d)
+	return err
+}
@@ -0,0 +1,116 @@
+package csv2json
+
+import (
+	"testing"
+)
+
+// TestMapKeyedOutput tests writing records as object keyed by property values.
+func TestMapKeyedOutput(t *testing.T) {
+	mappingJSON := `{
+		"mapping": {
+			"0": {"property": "id", "type": "int"},
+			"1": {"property": "country", "type": "string"},
+			"2": {"property": "name", "type": "string"}
+		}
+	}`
+	input := "2,DE,Jane\n1,US,John\n2,DE,Joe\n"
+
+	tests := []struct {
+		name       string
+		outputType string
+		options    []OptionFunc
+		want       string
+		wantErr    bool
+	}{
+		{
+			name:       "json duplicate error",
+			outputType: "json",
+			options:    []OptionFunc{WithArray(true), WithKeyBy("id")},
+			wantErr:    true,
+		},
+		{
+			name:       "json first",
+			outputType: "json",
+			options:    []OptionFunc{WithArray(true), WithKeyBy("id"), WithDuplicateKeys("first")},
+			want:       `{"1":{"country":"US","id":1,"name":"John"},"2":{"country":"DE","id":2,"name":"Jane"}}`,
+		},
+		{
+			name:       "json last",
+			outputType: "json",
+			options:    []OptionFunc{WithArray(true), WithKeyBy("id"), WithDuplicateKeys("last")},
+			want:       `{"1":{"country":"US","id":1,"name":"John"},"2":{"country":"DE","id":2,"name":"Joe"}}`,
+		},
+		{
+			name:       "json array ordered",
+			outputType: "json",
+			options:    []OptionFunc{WithArray(true), WithKeyBy("id"), WithDuplicateKeys("array"), WithOrdered(true)},
+			want: `{"2":[{"id":2,"country":"DE","name":"Jane"},{"id":2,"country":"DE","name":"Joe"}],` +
+				`"1":[{"id":1,"country":"US","name":"John"}]}`,
+		},
+		{
+			name:       "json composite key",
+			outputType: "json",
+			options:    []OptionFunc{WithArray(true), WithKeyBy("country", "name"), WithKeySeparator("/"), WithOrdered(true)},
+			want: `{"DE/Jane":{"id":2,"country":"DE","name":"Jane"},"US/John":{"id":1,"country":"US","name":"John"},` +
+				`"DE/Joe":{"id":2,"country":"DE","name":"Joe"}}`,
+		},
+		{
+			name:       "json nested property",
+			outputType: "json",
+			options:    []OptionFunc{WithArray(true), WithKeyBy("name"), WithNestedPropertyName("people")},
+			want: `{"people":{"Jane":{"country":"DE","id":2,"name":"Jane"},"Joe":{"country":"DE","id":2,"name":"Joe"},` +
+				`"John":{"country":"US","id":1,"name":"John"}}}`,
+		},
+		{
+			name:       "yaml",
+			outputType: "yaml",
+			options:    []OptionFunc{WithKeyBy("id"), WithDuplicateKeys("last"), WithOrdered(true)},
+			want:       "\"2\":\n    id: 2\n    country: DE\n    name: Joe\n\"1\":\n    id: 1\n    country: US\n    name: John\n",
+		},
+		{
+			name:       "toml",
+			outputType: "toml",
+			options:    []OptionFunc{WithKeyBy("id"), WithDuplicateKeys("first")},
+			want:       "[data]\n  [data.1]\n    country = \"US\"\n    id = 1\n    name = \"John\"\n  [data.2]\n    country = \"DE\"\n    id = 2\n    name = \"Jane\"\n",
+		},
+		{
+			name:       "json without array",
+			outputType: "json",
+			options:    []OptionFunc{WithKeyBy("id")},
+			wantErr:    true,
+		},
+		{
+			name:       "csv",
+			outputType: "csv",
+			options:    []OptionFunc{WithKeyBy("id")},
+			wantErr:    true,
+		},
+		{
+			name:       "unknown key property",
+			outputType: "json",
+			options:    []OptionFunc{WithArray(true), WithKeyBy("missing")},
+			wantErr:    true,
+		},
+		{
+			name:       "unknown duplicate key policy",
+			outputType: "json",
+			options:    []OptionFunc{WithArray(true), WithKeyBy("id"), WithDuplicateKeys("merge")},
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := mapToFile(t, mappingJSON, input, append([]OptionFunc{WithOutputType(tt.outputType)}, tt.options...)...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Map() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if string(data) != tt.want {
+				t.Errorf("Map() = %q, want %q", data, tt.want)
+			}
+		})
+	}
+}
@@ -1,12 +1,14 @@
 package csv2json
 
 import (
+	"bufio"
 	"encoding/csv"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"os"
+	"regexp"
 	"slices"
 	"strconv"
 	"strings"
@@ -19,13 +21,19 @@ import (
 // OptionFunc defines a function signature for configuring a Mapper instance with specific options or parameters.
 type OptionFunc func(*Mapper) error
 
-// WithIn sets the "in" field of the Mapper instance to the provided string if it's not empty, otherwise returns an error.
-func WithIn(in string) OptionFunc {
+// WithIn adds the provided inputs to the "in" field of the Mapper instance if they are not empty, otherwise returns an error.
+// An input is either a file path, a glob pattern or '-' for standard input.
+func WithIn(in ...string) OptionFunc {
 	return func(mapper *Mapper) error {
-		if strings.TrimSpace(in) == "" {
+		if len(in) == 0 {
 			return errors.New("-in may not be empty")
 		}
-		mapper.in = in
+		for _, i := range in {
+			if strings.TrimSpace(i) == "" {
+				return errors.New("-in may not be empty")
+			}
+		}
+		mapper.in = append(mapper.in, in...)
 		return nil
 	}
 }
@@ -83,6 +91,20 @@ func WithOutputType(outputType string) OptionFunc {
 		case "json":
 		case "yaml":
 		case "toml":
+		case "xml":
+		case "csv":
+		case "sql":
+		case "pgcopy":
+		case "bulk":
+		case "geojson":
+		case "msgpack":
+		case "cbor":
+		case "avro":
+		case "protobuf":
+		case "template":
+		case "markdown":
+		case "html":
+		case "table":
 			break
 		case "":
 			mapper.marshalWith = "json"
@@ -94,6 +116,476 @@ func WithOutputType(outputType string) OptionFunc {
 	}
 }
 
+// WithInputType sets the type of the input. csv, fixed-width, jsonl, json or ods
+func WithInputType(inputType string) OptionFunc {
+	return func(mapper *Mapper) error {
+		mapper.inputType = inputType
+		switch inputType {
+		case "csv":
+		case "fixed-width":
+		case "jsonl":
+		case "json":
+		case "ods":
+			break
+		case "":
+			mapper.inputType = "csv"
+			break
+		default:
+			return fmt.Errorf("unknown input type %q", inputType)
+		}
+		return nil
+	}
+}
+
+// WithSheet sets the name or 1-based index of the sheet read from spreadsheet input. Defaults to the first sheet.
+func WithSheet(sheet string) OptionFunc {
+	return func(mapper *Mapper) error {
+		mapper.sheet = sheet
+		return nil
+	}
+}
+
+// WithSkipLines sets the number of lines skipped at the beginning of every input.
+func WithSkipLines(lines int) OptionFunc {
+	return func(mapper *Mapper) error {
+		if lines < 0 {
+			return fmt.Errorf("-skip-lines may not be negative (%d)", lines)
+		}
+		mapper.skipLines = lines
+		return nil
+	}
+}
+
+// WithSkipUntil sets a regular expression, lines at the beginning of every input are skipped until a line matches.
+func WithSkipUntil(pattern string) OptionFunc {
+	return func(mapper *Mapper) error {
+		if pattern == "" {
+			return nil
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid -skip-until pattern: %w", err)
+		}
+		mapper.skipUntil = re
+		return nil
+	}
+}
+
+// WithHeaderRow sets the 1-based row of the header, rows before the header are dropped.
+func WithHeaderRow(row int) OptionFunc {
+	return func(mapper *Mapper) error {
+		if row < 0 {
+			return fmt.Errorf("-header-row may not be negative (%d)", row)
+		}
+		mapper.headerRow = row
+		return nil
+	}
+}
+
+// WithSkipTrailer sets the number of rows dropped at the end of every input.
+func WithSkipTrailer(rows int) OptionFunc {
+	return func(mapper *Mapper) error {
+		if rows < 0 {
+			return fmt.Errorf("-skip-trailer may not be negative (%d)", rows)
+		}
+		mapper.skipTrailer = rows
+		return nil
+	}
+}
+
+// WithTrailerPattern sets a regular expression, rows matching it are dropped.
+func WithTrailerPattern(pattern string) OptionFunc {
+	return func(mapper *Mapper) error {
+		if pattern == "" {
+			return nil
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid -trailer-pattern: %w", err)
+		}
+		mapper.trailerPattern = re
+		return nil
+	}
+}
+
+// WithIndent sets the number of spaces used to indent JSON output. 0 writes compact JSON.
+func WithIndent(indent int) OptionFunc {
+	return func(mapper *Mapper) error {
+		if indent < 0 {
+			return errors.New("-indent may not be negative")
+		}
+		mapper.encoder.indent = indent
+		return nil
+	}
+}
+
+// WithNoHTMLEscape disables escaping <, > and & in JSON output.
+func WithNoHTMLEscape(noHTMLEscape bool) OptionFunc {
+	return func(mapper *Mapper) error {
+		mapper.encoder.noHTMLEscape = noHTMLEscape
+		return nil
+	}
+}
+
+// WithASCII escapes all non-ASCII characters in JSON output.
+func WithASCII(ascii bool) OptionFunc {
+	return func(mapper *Mapper) error {
+		mapper.encoder.ascii = ascii
+		return nil
+	}
+}
+
+// WithNoExponent writes floats in JSON output without exponent notation.
+func WithNoExponent(noExponent bool) OptionFunc {
+	return func(mapper *Mapper) error {
+		mapper.encoder.noExponent = noExponent
+		return nil
+	}
+}
+
+// WithFloatDecimals sets a fixed number of decimals for floats in JSON output. -1 writes the shortest representation.
+func WithFloatDecimals(decimals int) OptionFunc {
+	return func(mapper *Mapper) error {
+		if decimals < -1 {
+			return errors.New("-float-decimals must be -1 or greater")
+		}
+		mapper.encoder.decimals = decimals
+		return nil
+	}
+}
+
+// WithOrdered writes properties in the order they are declared in the mapping instead of sorted by name.
+func WithOrdered(ordered bool) OptionFunc {
+	return func(mapper *Mapper) error {
+		mapper.ordered = ordered
+		return nil
+	}
+}
+
+// WithYAMLStream writes every record as a separate document of a YAML stream instead of a single array.
+func WithYAMLStream(stream bool) OptionFunc {
+	return func(mapper *Mapper) error {
+		mapper.yamlStream = stream
+		return nil
+	}
+}
+
+// WithYAMLDocument writes document-level fields as a leading or trailing document of a YAML stream.
+// leading, trailing or empty to omit them
+func WithYAMLDocument(position string) OptionFunc {
+	return func(mapper *Mapper) error {
+		switch position {
+		case "", "leading", "trailing":
+			mapper.yamlDocument = position
+			return nil
+		}
+		return fmt.Errorf("unknown yaml document position %q", position)
+	}
+}
+
+// WithRecordElement sets the name of the element written for every record in XML output.
+func WithRecordElement(name string) OptionFunc {
+	return func(mapper *Mapper) error {
+		if !xmlName.MatchString(name) {
+			return fmt.Errorf("%q is not a valid XML element name", name)
+		}
+		mapper.xmlRecordElement = name
+		return nil
+	}
+}
+
+// WithHeaderElement sets the name of the element holding document-level fields in XML output.
+func WithHeaderElement(name string) OptionFunc {
+	return func(mapper *Mapper) error {
+		if !xmlName.MatchString(name) {
+			return fmt.Errorf("%q is not a valid XML element name", name)
+		}
+		mapper.xmlHeaderElement = name
+		return nil
+	}
+}
+
+// WithOutputSeparator sets the separator of CSV output.
+func WithOutputSeparator(separator string) OptionFunc {
+	return func(mapper *Mapper) error {
+		if len(separator) != 1 {
+			return fmt.Errorf("output separator must be 1 character long (%q)", separator)
+		}
+		mapper.outputSeparator = rune(separator[0])
+		return nil
+	}
+}
+
+// WithFlattenSeparator sets the separator joining the parts of nested properties in the header of CSV output.
+func WithFlattenSeparator(separator string) OptionFunc {
+	return func(mapper *Mapper) error {
+		if separator == "" {
+			return errors.New("-flatten-separator may not be empty")
+		}
+		mapper.flattenSeparator = separator
+		return nil
+	}
+}
+
+// WithQuoting sets which cells of CSV output are quoted. minimal, strings or all
+func WithQuoting(quoting string) OptionFunc {
+	return func(mapper *Mapper) error {
+		switch quoting {
+		case "minimal", "strings", "all":
+			mapper.quoting = quoting
+		case "":
+			mapper.quoting = "minimal"
+		default:
+			return fmt.Errorf("unknown quoting %q", quoting)
+		}
+		return nil
+	}
+}
+
+// WithAllowFormulas writes text cells of CSV output that start like a spreadsheet formula unchanged.
+func WithAllowFormulas(allow bool) OptionFunc {
+	return func(mapper *Mapper) error {
+		mapper.allowFormulas = allow
+		return nil
+	}
+}
+
+// WithDialect sets the SQL dialect of SQL output. postgres, mysql or sqlite
+func WithDialect(dialect string) OptionFunc {
+	return func(mapper *Mapper) error {
+		switch dialect {
+		case "postgres", "mysql", "sqlite":
+			mapper.dialect = dialect
+		case "":
+			mapper.dialect = "postgres"
+		default:
+			return fmt.Errorf("unknown sql dialect %q", dialect)
+		}
+		return nil
+	}
+}
+
+// WithTable sets the table SQL output inserts into.
+func WithTable(table string) OptionFunc {
+	return func(mapper *Mapper) error {
+		if table == "" {
+			return errors.New("-table may not be empty")
+		}
+		mapper.table = table
+		return nil
+	}
+}
+
+// WithBatchSize sets the number of records per INSERT statement.
+func WithBatchSize(size int) OptionFunc {
+	return func(mapper *Mapper) error {
+		if size < 1 {
+			return errors.New("-batch-size must be at least 1")
+		}
+		mapper.batchSize = size
+		return nil
+	}
+}
+
+// WithUpsertKeys sets the columns identifying existing rows, turning INSERT statements into upserts.
+// Keys may be given as property path or column name.
+func WithUpsertKeys(keys ...string) OptionFunc {
+	return func(mapper *Mapper) error {
+		for _, key := range keys {
+			if key == "" {
+				return errors.New("upsert key may not be empty")
+			}
+		}
+		mapper.upsertKeys = keys
+		return nil
+	}
+}
+
+// WithCreateTable writes a CREATE TABLE statement derived from the mapping before the INSERT statements.
+func WithCreateTable(createTable bool) OptionFunc {
+	return func(mapper *Mapper) error {
+		mapper.createTable = createTable
+		return nil
+	}
+}
+
+// WithCopyFormat sets the format of PostgreSQL COPY output. text or binary
+func WithCopyFormat(format string) OptionFunc {
+	return func(mapper *Mapper) error {
+		switch format {
+		case "text", "binary":
+			mapper.copyFormat = format
+		case "":
+			mapper.copyFormat = "text"
+		default:
+			return fmt.Errorf("unknown copy format %q", format)
+		}
+		return nil
+	}
+}
+
+// WithBulkAction sets the action of bulk output. index, create or update
+func WithBulkAction(action string) OptionFunc {
+	return func(mapper *Mapper) error {
+		switch action {
+		case "index", "create", "update":
+			mapper.bulkAction = action
+		case "":
+			mapper.bulkAction = "index"
+		default:
+			return fmt.Errorf("unknown bulk action %q", action)
+		}
+		return nil
+	}
+}
+
+// WithBulkIndexProperty sets the property providing the _index of every bulk action.
+func WithBulkIndexProperty(property string) OptionFunc {
+	return func(mapper *Mapper) error {
+		mapper.bulkIndexProperty = property
+		return nil
+	}
+}
+
+// WithBulkIDProperty sets the property providing the _id of every bulk action.
+func WithBulkIDProperty(property string) OptionFunc {
+	return func(mapper *Mapper) error {
+		mapper.bulkIDProperty = property
+		return nil
+	}
+}
+
+// WithBulkMaxDocuments limits the number of documents per chunk file of bulk output. 0 disables the limit.
+func WithBulkMaxDocuments(documents int) OptionFunc {
+	return func(mapper *Mapper) error {
+		if documents < 0 {
+			return errors.New("-bulk-max-documents may not be negative")
+		}
+		mapper.bulkMaxDocuments = documents
+		return nil
+	}
+}
+
+// WithBulkMaxBytes limits the size in bytes of a chunk file of bulk output. 0 disables the limit.
+func WithBulkMaxBytes(size int) OptionFunc {
+	return func(mapper *Mapper) error {
+		if size < 0 {
+			return errors.New("-bulk-max-bytes may not be negative")
+		}
+		mapper.bulkMaxBytes = size
+		return nil
+	}
+}
+
+// WithAvroCodec sets the compression codec of Avro output blocks. null or deflate
+func WithAvroCodec(codec string) OptionFunc {
+	return func(mapper *Mapper) error {
+		switch codec {
+		case "null", "deflate":
+			mapper.avroCodec = codec
+		case "":
+			mapper.avroCodec = "null"
+		default:
+			return fmt.Errorf("unknown avro codec %q", codec)
+		}
+		return nil
+	}
+}
+
+// WithAvroName sets the name of the Avro record schema.
+func WithAvroName(name string) OptionFunc {
+	return func(mapper *Mapper) error {
+		if name == "" {
+			return errors.New("-avro-name may not be empty")
+		}
+		mapper.avroName = name
+		return nil
+	}
+}
+
+// WithAvroSchemaFile writes the Avro schema derived from the mapping to the given file, '-' for standard output.
+func WithAvroSchemaFile(file string) OptionFunc {
+	return func(mapper *Mapper) error {
+		mapper.avroSchemaFile = file
+		return nil
+	}
+}
+
+// WithProtoMessage sets the name of the protobuf message of the records.
+func WithProtoMessage(name string) OptionFunc {
+	return func(mapper *Mapper) error {
+		if name == "" {
+			return errors.New("-proto-message may not be empty")
+		}
+		mapper.protoMessage = name
+		return nil
+	}
+}
+
+// WithProtoFile writes the .proto definition derived from the mapping to the given file.
+func WithProtoFile(file string) OptionFunc {
+	return func(mapper *Mapper) error {
+		mapper.protoFile = file
+		return nil
+	}
+}
+
+// WithTemplate sets the text/template file of template output.
+func WithTemplate(file string) OptionFunc {
+	return func(mapper *Mapper) error {
+		mapper.templateFile = file
+		return nil
+	}
+}
+
+// WithMaxWidth limits the width of the columns of table output, longer cells are truncated. 0 disables the limit.
+func WithMaxWidth(width int) OptionFunc {
+	return func(mapper *Mapper) error {
+		if width < 0 {
+			return errors.New("-max-width may not be negative")
+		}
+		mapper.maxWidth = width
+		return nil
+	}
+}
+
+// WithKeyBy writes the records as object keyed by the values of the given properties instead of an array.
+// Values of multiple properties are joined to a composite key.
+func WithKeyBy(properties ...string) OptionFunc {
+	return func(mapper *Mapper) error {
+		for _, property := range properties {
+			if property == "" {
+				return errors.New("key property may not be empty")
+			}
+		}
+		mapper.keyBy = properties
+		return nil
+	}
+}
+
+// WithKeySeparator sets the separator joining the values of composite keys.
+func WithKeySeparator(separator string) OptionFunc {
+	return func(mapper *Mapper) error {
+		mapper.keySeparator = separator
+		return nil
+	}
+}
+
+// WithDuplicateKeys sets the policy for records sharing a key in keyed object output. error, first, last or array
+func WithDuplicateKeys(policy string) OptionFunc {
+	return func(mapper *Mapper) error {
+		switch policy {
+		case "error", "first", "last", "array":
+			mapper.duplicateKeys = policy
+		case "":
+			mapper.duplicateKeys = "error"
+		default:
+			return fmt.Errorf("unknown duplicate key policy %q", policy)
+		}
+		return nil
+	}
+}
+
 // WithNestedPropertyName sets the property name for TOML array output.
 func WithNestedPropertyName(propertyName string) OptionFunc {
 	return func(mapper *Mapper) error {
@@ -104,140 +596,216 @@ func WithNestedPropertyName(propertyName string) OptionFunc {
 
 // NewMapper creates and initializes a new Mapper instance using the provided OptionFunc configurations.
 func NewMapper(options ...OptionFunc) (*Mapper, error) {
-	mapper := &Mapper{separator: ','}
+	mapper := &Mapper{separator: ',', encoder: jsonEncoder{decimals: -1}, xmlRecordElement: "record", xmlHeaderElement: "header",
+		outputSeparator: ',', flattenSeparator: ".", quoting: "minimal", dialect: "postgres", table: "data", batchSize: 100, copyFormat: "text",
+		bulkAction: "index", avroCodec: "null", avroName: "record", protoMessage: "Record",
+		keySeparator: ":", duplicateKeys: "error"}
 	for _, option := range options {
 		if err := option(mapper); err != nil {
 			return nil, err
 		}
 	}
+	if mapper.headerRow > 1 && !mapper.named {
+		return nil, errors.New("-header-row requires named input")
+	}
+	if mapper.yamlStream && (mapper.marshalWith != "yaml" || mapper.array || mapper.nestedPropertyName != "") {
+		return nil, errors.New("-yaml-stream requires yaml output and can not be combined with -array or -nested-property")
+	}
+	if mapper.marshalWith == "xml" && mapper.nestedPropertyName != "" && !xmlName.MatchString(mapper.nestedPropertyName) {
+		return nil, fmt.Errorf("%q is not a valid XML element name", mapper.nestedPropertyName)
+	}
+	if slices.Contains(recordOutputs, mapper.marshalWith) && (mapper.array || mapper.nestedPropertyName != "") {
+		return nil, fmt.Errorf("%s output can not be combined with -array or -nested-property", mapper.marshalWith)
+	}
+	if (mapper.marshalWith == "template") != (mapper.templateFile != "") {
+		return nil, errors.New("-output-type template and -template have to be used together")
+	}
+	if mapper.marshalWith == "geojson" && mapper.nestedPropertyName != "" {
+		return nil, errors.New("geojson output can not be combined with -nested-property")
+	}
+	if (mapper.bulkMaxDocuments > 0 || mapper.bulkMaxBytes > 0) && mapper.out == "-" {
+		return nil, errors.New("splitting bulk output requires an output file")
+	}
+	if mapper.avroSchemaFile == "-" && mapper.out == "-" {
+		return nil, errors.New("printing the avro schema requires an output file for the records")
+	}
+	if mapper.yamlDocument != "" && !mapper.yamlStream {
+		return nil, errors.New("-yaml-document requires -yaml-stream")
+	}
 	switch mapper.marshalWith {
 	case "json":
-		mapper.marshaler = json.Marshal
+		mapper.marshaler = mapper.encoder.marshal
 		break
 	case "yaml":
-		mapper.array = true
+		mapper.array = !mapper.yamlStream
 		mapper.marshaler = yaml.Marshal
 		break
 	case "toml":
 		mapper.array = true
-		mapper.marshaler = toml.Marshal
+		mapper.marshaler = func(v any) ([]byte, error) {
+			return toml.Marshal(orderedStructs(v))
+		}
 		break
 	}
+	if len(mapper.keyBy) > 0 && (!slices.Contains([]string{"json", "yaml", "toml"}, mapper.marshalWith) || !mapper.array) {
+		return nil, errors.New("-key-by requires json, yaml or toml output written as array")
+	}
 	return mapper, nil
 }
 
 // Map processes input CSV data, maps it to JSON according to the configuration, and writes the result to the output destination.
 func (m *Mapper) Map() error {
-	reader, writer, err := m.initialize()
+	inputs, writer, err := m.initialize()
 	if err != nil {
 		return err
 	}
-	defer reader.Close()
 	defer writer.Close()
+	buffered := bufio.NewWriter(writer)
+	defer buffered.Flush()
+
+	if err = m.checkInputHeaders(inputs); err != nil {
+		return err
+	}
+	recordOrder, documentOrder := m.propertyOrder()
+	// document collects rows of record types located in the document
+	document := make(map[string]any)
+	records, err := m.newRecordWriter(buffered, document, recordOrder, documentOrder)
+	if err != nil {
+		return err
+	}
+	switch w := records.(type) {
+	case *xmlWriter:
+		defer w.removeSpool()
+	case *binaryWriter:
+		defer w.removeSpool()
+	case *bulkWriter:
+		defer w.closeChunk()
+	}
+	recordNumber := 0
+	for _, in := range inputs {
+		recordNumber, err = m.mapInput(in, recordNumber, document, records.write)
+		if err != nil {
+			return err
+		}
+	}
+	if (m.array && m.documentProperty() != "") || m.marshalWith == "xml" || m.yamlDocument == "trailing" {
+		document, err = m.applyCalculatedFields(m.configuration.Calculated, nil, nil, recordNumber, document, "document")
+		if err != nil {
+			return err
+		}
+	}
+	if err = records.close(document); err != nil {
+		return err
+	}
+	return buffered.Flush()
+}
 
-	csvIn := csv.NewReader(reader)
-	csvIn.Comma = m.separator
-	csvIn.ReuseRecord = false
+// mapInput reads all records of a single input, maps them and hands them to emit. The record number is continued
+// from the given value, the updated record number is returned. Rows of record types located in the document are
+// added to document.
+func (m *Mapper) mapInput(in string, recordNumber int, document map[string]any, emit func(out map[string]any) error) (int, error) {
+	reader, err := openInput(in)
+	if err != nil {
+		return recordNumber, err
+	}
+	defer reader.Close()
 
-	var (
-		arrResult []map[string]any
-		header    []string
-	)
+	records, err := m.newRecordReader(reader)
+	if err != nil {
+		return recordNumber, err
+	}
 
-	// Read header if needed
+	var header []string
+	// Read header if needed, every input has its own header
 	if m.named {
-		header, err = csvIn.Read()
+		header, err = records.readHeader()
 		if err != nil {
-			return err
+			return recordNumber, fmt.Errorf("%s: %w", in, err)
 		}
+		keys := m.headerKeys(header)
+		if in == "-" {
+			// the headers of all other inputs were checked before any record was written
+			if err = m.checkSchemaDrift(in, header, keys); err != nil {
+				return recordNumber, err
+			}
+		}
+		// from now on the header holds the mapping keys the columns resolve to
+		header = keys
 	}
-	// from now on we can reuse the record
-	csvIn.ReuseRecord = true
-	if m.array {
-		arrResult = make([]map[string]any, 0)
-	}
-	recordNumber := 0
+	m.currentIn = in
+	m.currentInRecord = 0
 	// Read all records
 	for {
-		record, err := csvIn.Read()
+		record, err := records.read()
 		if err == io.EOF {
 			break
 		}
 		if err != nil {
-			return err
+			return recordNumber, fmt.Errorf("%s: %w", in, err)
 		}
-		out := make(map[string]interface{})
-		out, err = m.mapCSVFields(record, header, out)
+		out, err := m.mapRecord(record, header, recordNumber, document)
 		if err != nil {
-			return err
+			return recordNumber, fmt.Errorf("%s: %w", in, err)
 		}
-		// calculated fields
-		out, err = m.applyCalculatedFields(record, header, recordNumber, out, "record")
-		if err != nil {
-			return err
+		if out == nil {
+			// the row was added to the document or skipped
+			continue
 		}
-		if m.array {
-			arrResult = append(arrResult, out)
-		} else {
-			d, err := m.marshaler(out)
-			if err != nil {
-				return err
-			}
-			if recordNumber > 0 {
-				_, _ = writer.Write([]byte("\n"))
-			}
-			_, err = writer.Write(d)
-			if err != nil {
-				return err
-			}
+		if err = emit(out); err != nil {
+			return recordNumber, err
 		}
 		recordNumber++
+		m.currentInRecord++
 	}
-	if m.array {
-		var d []byte
-		if m.marshalWith == "toml" || m.nestedPropertyName != "" {
-			// Set default property name if not specified
-			propertyName := "data"
-			if m.nestedPropertyName != "" {
-				propertyName = m.nestedPropertyName
-			}
+	return recordNumber, nil
+}
 
-			// Create a map with the custom property name as the key
-			outputData := map[string]any{
-				propertyName: arrResult,
-			}
-			outputData, err = m.applyCalculatedFields(nil, nil, recordNumber, outputData, "document")
-			if err != nil {
-				return err
-			}
-			d, err = m.marshaler(outputData)
-			if err != nil {
-				return err
-			}
-		} else {
-			d, err = m.marshaler(arrResult)
-			if err != nil {
-				return err
-			}
+// mapRecord maps a single record using the mapping and calculated fields of the configuration or, if record types
+// are configured, of the record type of the row. Rows of record types located in the document are added to document
+// and nil is returned.
+func (m *Mapper) mapRecord(record, header []string, recordNumber int, document map[string]any) (map[string]any, error) {
+	mapping, calculated := m.configuration.Mapping, m.configuration.Calculated
+	if len(m.configuration.RecordTypes.Types) > 0 {
+		name, recordType, err := m.recordType(record)
+		if err != nil || recordType == nil {
+			return nil, err
+		}
+		if recordType.Location == "document" {
+			return nil, m.addDocumentRecord(name, *recordType, record, recordNumber, document)
 		}
-		_, _ = writer.Write(d)
+		mapping = recordType.Mapping
+		calculated = append(slices.Clip(calculated), recordType.Calculated...)
+	}
+	out := make(map[string]interface{})
+	out, err := m.mapCSVFields(mapping, record, header, out)
+	if err != nil {
+		return nil, err
 	}
-	return nil
+	// calculated fields
+	return m.applyCalculatedFields(calculated, record, header, recordNumber, out, "record")
 }
 
-// mapCSVFields maps CSV records to a nested output structure using a header and mapping configuration. Returns the updated map or an error.
-func (m *Mapper) mapCSVFields(record []string, header []string, out map[string]any) (map[string]any, error) {
+// mapCSVFields maps CSV records to a nested output structure using a header and mapping configuration. Columns
+// without mapping are skipped. Returns the updated map or an error.
+func (m *Mapper) mapCSVFields(mapping map[string]ColumnConfiguration, record []string, header []string, out map[string]any) (map[string]any, error) {
 	for i := range record {
 		key := fmt.Sprintf("%d", i)
 		if m.named {
+			if i >= len(header) {
+				return nil, fmt.Errorf("record has %d fields, header has %d: %w", len(record), len(header), csv.ErrFieldCount)
+			}
 			key = header[i]
 		}
 		var (
 			v  ColumnConfiguration
 			ok bool
 		)
-		if v, ok = m.configuration.Mapping[key]; !ok {
-			return out, nil
+		if v, ok = mapping[key]; !ok {
+			continue
+		}
+		if v.Optional && record[i] == "" {
+			// empty optional columns are left out of the record
+			continue
 		}
 		val, err := convertToType(v.Type, record[i])
 		if err != nil {
@@ -249,10 +817,10 @@ func (m *Mapper) mapCSVFields(record []string, header []string, out map[string]a
 }
 
 // applyCalculatedFields applies calculated fields to the output based on the configuration and specified record number.
-func (m *Mapper) applyCalculatedFields(record, header []string, recordNumber int, out map[string]any, loc string) (map[string]any, error) {
+func (m *Mapper) applyCalculatedFields(calculated []CalculatedField, record, header []string, recordNumber int, out map[string]any, loc string) (map[string]any, error) {
 	var err error
 
-	for _, field := range m.configuration.Calculated {
+	for _, field := range calculated {
 		if field.Location != loc {
 			continue
 		}
@@ -286,6 +854,15 @@ func (m *Mapper) applyCalculatedFields(record, header []string, recordNumber int
 			if err != nil {
 				return nil, err
 			}
+		case "preamble":
+			e, ok := m.preambleVariables[field.Format]
+			if !ok {
+				return nil, errors.New("preamble variable " + field.Format + " not found")
+			}
+			val, err = convertToType(field.Type, e)
+			if err != nil {
+				return nil, err
+			}
 		case "mapping":
 			if record == nil {
 				continue
@@ -296,10 +873,11 @@ func (m *Mapper) applyCalculatedFields(record, header []string, recordNumber int
 			}
 			var currentValue string
 			if m.named {
-				if !slices.Contains(header, splitFormat[0]) {
+				column := m.columnKey(splitFormat[0])
+				if !slices.Contains(header, column) {
 					return nil, errors.New("mapping field " + splitFormat[0] + " not found in header")
 				}
-				currentValue = record[slices.Index(header, splitFormat[0])]
+				currentValue = record[slices.Index(header, column)]
 			} else {
 				var i int
 				if i, err = strconv.Atoi(splitFormat[0]); err != nil {
@@ -353,12 +931,16 @@ func (m *Mapper) getApplicationValue(field CalculatedField, i int) (any, error)
 		return convertToType("int", strconv.Itoa(i))
 	case "records":
 		return convertToType("int", strconv.Itoa(i))
+	case "file":
+		return m.currentIn, nil
+	case "file_record":
+		return convertToType("int", strconv.Itoa(m.currentInRecord))
 	}
 	return nil, errors.New("unknown format " + field.Format)
 }
 
-// initialize initializes the Mapper instance by reading the mapping file and opening the input and output files.
-func (m *Mapper) initialize() (io.ReadCloser, io.WriteCloser, error) {
+// initialize initializes the Mapper instance by reading the mapping file, resolving the input files and opening the output file.
+func (m *Mapper) initialize() ([]string, io.WriteCloser, error) {
 	mappingFile := "mapping.json"
 	if m.mappingFile != "" {
 		mappingFile = m.mappingFile
@@ -372,18 +954,29 @@ func (m *Mapper) initialize() (io.ReadCloser, io.WriteCloser, error) {
 	if err := json.Unmarshal(configData, &m.configuration); err != nil {
 		return nil, nil, fmt.Errorf("failed to parse mapping file: %w", err)
 	}
-	var (
-		fIn  io.ReadCloser
-		fOut io.WriteCloser
-	)
-	if m.in == "-" {
-		fIn = os.Stdin
-	} else {
-		fIn, err = os.OpenFile(m.in, os.O_RDONLY, 0600)
-		if err != nil {
-			return nil, nil, err
-		}
+	if err := m.prepareColumns(); err != nil {
+		return nil, nil, err
+	}
+	if err := m.validateSchemaDrift(); err != nil {
+		return nil, nil, err
+	}
+	if err := m.compilePreamble(); err != nil {
+		return nil, nil, err
+	}
+	if err := m.validateYAMLDocument(); err != nil {
+		return nil, nil, err
+	}
+	if err := m.validateRecordTypes(); err != nil {
+		return nil, nil, err
+	}
+	if err := m.validateDocumentFields(); err != nil {
+		return nil, nil, err
+	}
+	inputs, err := resolveInputs(m.in)
+	if err != nil {
+		return nil, nil, err
 	}
+	var fOut io.WriteCloser
 	if m.out == "-" {
 		fOut = os.Stdout
 	} else {
@@ -392,5 +985,5 @@ func (m *Mapper) initialize() (io.ReadCloser, io.WriteCloser, error) {
 			return nil, nil, err
 		}
 	}
-	return fIn, fOut, nil
+	return inputs, fOut, nil
 }
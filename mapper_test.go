@@ -6,6 +6,7 @@ import (
 	"io"
 	"os"
 	"reflect"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -26,7 +27,7 @@ func TestCreate(t *testing.T) {
 				WithOut("output.json"),
 			},
 			want: &Mapper{
-				in:  "input.csv",
+				in:  []string{"input.csv"},
 				out: "output.json",
 			},
 			wantErr: false,
@@ -40,7 +41,7 @@ func TestCreate(t *testing.T) {
 				WithNamed(true),
 			},
 			want: &Mapper{
-				in:    "input.csv",
+				in:    []string{"input.csv"},
 				out:   "output.json",
 				array: true,
 				named: true,
@@ -319,7 +320,7 @@ func TestMap(t *testing.T) {
 	}
 }
 
-// TestInitialize tests the initialize method which reads the mapping file and opens input/output files.
+// TestInitialize tests the initialize method which reads the mapping file, resolves the inputs and opens the output file.
 func TestInitialize(t *testing.T) {
 	// Create a temporary mapping file
 	mappingJSON := `{
@@ -374,7 +375,7 @@ func TestInitialize(t *testing.T) {
 		{
 			name: "successful initialization",
 			mapper: &Mapper{
-				in:          tempCSVFile.Name(),
+				in:          []string{tempCSVFile.Name()},
 				out:         tempOutFile.Name(),
 				mappingFile: tempMappingFile.Name(),
 			},
@@ -383,7 +384,7 @@ func TestInitialize(t *testing.T) {
 		{
 			name: "non-existent mapping file",
 			mapper: &Mapper{
-				in:          tempCSVFile.Name(),
+				in:          []string{tempCSVFile.Name()},
 				out:         tempOutFile.Name(),
 				mappingFile: "non_existent_file.json",
 			},
@@ -393,7 +394,7 @@ func TestInitialize(t *testing.T) {
 		{
 			name: "invalid mapping file",
 			mapper: &Mapper{
-				in:          tempCSVFile.Name(),
+				in:          []string{tempCSVFile.Name()},
 				out:         tempOutFile.Name(),
 				mappingFile: tempCSVFile.Name(), // Using CSV file as mapping file will cause JSON parsing error
 			},
@@ -403,7 +404,7 @@ func TestInitialize(t *testing.T) {
 		{
 			name: "non-existent input file",
 			mapper: &Mapper{
-				in:          "non_existent_file.csv",
+				in:          []string{"non_existent_file.csv"},
 				out:         tempOutFile.Name(),
 				mappingFile: tempMappingFile.Name(),
 			},
@@ -413,7 +414,7 @@ func TestInitialize(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			reader, writer, err := tt.mapper.initialize()
+			inputs, writer, err := tt.mapper.initialize()
 
 			// Check error
 			if (err != nil) != tt.wantErr {
@@ -427,12 +428,10 @@ func TestInitialize(t *testing.T) {
 				return
 			}
 
-			// Check if reader and writer are non-nil when no error
+			// Check if inputs are resolved and writer is non-nil when no error
 			if !tt.wantErr {
-				if reader == nil {
-					t.Errorf("initialize() reader is nil")
-				} else {
-					reader.Close()
+				if len(inputs) != 1 || inputs[0] != tt.mapper.in[0] {
+					t.Errorf("initialize() inputs = %v, want %v", inputs, tt.mapper.in)
 				}
 
 				if writer == nil {
@@ -815,8 +814,115 @@ func compareMappers(a, b *Mapper) bool {
 	if a == nil || b == nil {
 		return a == b
 	}
-	return a.in == b.in &&
+	return slices.Equal(a.in, b.in) &&
 		a.out == b.out &&
 		a.array == b.array &&
 		a.named == b.named
 }
+
+// TestMapUnmappedColumn tests that columns following an unmapped column are still mapped. Earlier versions
+// stopped mapping a row at the first unmapped column, previously holds the output they produced.
+func TestMapUnmappedColumn(t *testing.T) {
+	tests := []struct {
+		name       string
+		named      bool
+		mapping    string
+		input      string
+		want       string
+		previously string
+	}{
+		{
+			name:       "column index",
+			mapping:    `{"mapping": {"0": {"property": "id", "type": "int"}, "2": {"property": "name", "type": "string"}}}`,
+			input:      "1,ignored,Jane\n",
+			want:       `[{"id":1,"name":"Jane"}]`,
+			previously: `[{"id":1}]`,
+		},
+		{
+			name:       "named column",
+			named:      true,
+			mapping:    `{"mapping": {"id": {"property": "id", "type": "int"}, "name": {"property": "name", "type": "string"}}}`,
+			input:      "id,comment,name\n1,ignored,Jane\n",
+			want:       `[{"id":1,"name":"Jane"}]`,
+			previously: `[{"id":1}]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := mapToFile(t, tt.mapping, tt.input, WithNamed(tt.named), WithArray(true), WithOutputType("json"))
+			if err != nil {
+				t.Fatalf("Failed to map: %v", err)
+			}
+			if string(data) == tt.previously {
+				t.Errorf("Map() = %s, columns after the unmapped column are missing", data)
+			}
+			if string(data) != tt.want {
+				t.Errorf("Map() = %s, want %s", data, tt.want)
+			}
+		})
+	}
+}
+
+// TestMapCSVFields tests mapping the fields of a record by column index or header name.
+func TestMapCSVFields(t *testing.T) {
+	mapping := map[string]ColumnConfiguration{
+		"0":    {Property: "id", Type: "int"},
+		"id":   {Property: "id", Type: "int"},
+		"name": {Property: "name", Type: "string"},
+	}
+
+	tests := []struct {
+		name    string
+		named   bool
+		record  []string
+		header  []string
+		want    map[string]any
+		wantErr bool
+	}{
+		{
+			name:   "by index",
+			record: []string{"1", "Jane"},
+			want:   map[string]any{"id": 1},
+		},
+		{
+			name:   "by header name",
+			named:  true,
+			record: []string{"1", "Jane"},
+			header: []string{"id", "name"},
+			want:   map[string]any{"id": 1, "name": "Jane"},
+		},
+		{
+			name:   "unmapped column by index",
+			record: []string{"2", "ignored"},
+			want:   map[string]any{"id": 2},
+		},
+		{
+			name:   "unmapped column in the middle",
+			named:  true,
+			record: []string{"1", "ignored", "Jane"},
+			header: []string{"id", "comment", "name"},
+			want:   map[string]any{"id": 1, "name": "Jane"},
+		},
+		{
+			name:    "record wider than header",
+			named:   true,
+			record:  []string{"1", "Jane", "extra"},
+			header:  []string{"id", "name"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Mapper{named: tt.named}
+			got, err := m.mapCSVFields(mapping, tt.record, tt.header, make(map[string]any))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("mapCSVFields() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mapCSVFields() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package csv2json
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+)
+
+// msgpackFormat writes MessagePack. Integers use the smallest representation, arrays and maps always have a
+// known length.
+type msgpackFormat struct{}
+
+// encodeNil writes nil.
+func (msgpackFormat) encodeNil(buf *bytes.Buffer) {
+	buf.WriteByte(0xc0)
+}
+
+// encodeBool writes false or true.
+func (msgpackFormat) encodeBool(buf *bytes.Buffer, value bool) {
+	if value {
+		buf.WriteByte(0xc3)
+	} else {
+		buf.WriteByte(0xc2)
+	}
+}
+
+// encodeInt writes a fixint or the smallest int format, non-negative values are written as unsigned.
+func (f msgpackFormat) encodeInt(buf *bytes.Buffer, value int64) {
+	switch {
+	case value >= 0:
+		f.encodeUint(buf, uint64(value))
+	case value >= -32:
+		buf.WriteByte(byte(value))
+	case value >= math.MinInt8:
+		buf.Write([]byte{0xd0, byte(value)})
+	case value >= math.MinInt16:
+		buf.WriteByte(0xd1)
+		buf.Write(binary.BigEndian.AppendUint16(nil, uint16(value)))
+	case value >= math.MinInt32:
+		buf.WriteByte(0xd2)
+		buf.Write(binary.BigEndian.AppendUint32(nil, uint32(value)))
+	default:
+		buf.WriteByte(0xd3)
+		buf.Write(binary.BigEndian.AppendUint64(nil, uint64(value)))
+	}
+}
+
+// encodeUint writes a positive fixint or the smallest uint format.
+func (msgpackFormat) encodeUint(buf *bytes.Buffer, value uint64) {
+	switch {
+	case value <= 0x7f:
+		buf.WriteByte(byte(value))
+	case value <= math.MaxUint8:
+		buf.Write([]byte{0xcc, byte(value)})
+	case value <= math.MaxUint16:
+		buf.WriteByte(0xcd)
+		buf.Write(binary.BigEndian.AppendUint16(nil, uint16(value)))
+	case value <= math.MaxUint32:
+		buf.WriteByte(0xce)
+		buf.Write(binary.BigEndian.AppendUint32(nil, uint32(value)))
+	default:
+		buf.WriteByte(0xcf)
+		buf.Write(binary.BigEndian.AppendUint64(nil, value))
+	}
+}
+
+// encodeFloat writes a float 32 or float 64.
+func (msgpackFormat) encodeFloat(buf *bytes.Buffer, value float64, bits int) {
+	if bits == 32 {
+		buf.WriteByte(0xca)
+		buf.Write(binary.BigEndian.AppendUint32(nil, math.Float32bits(float32(value))))
+		return
+	}
+	buf.WriteByte(0xcb)
+	buf.Write(binary.BigEndian.AppendUint64(nil, math.Float64bits(value)))
+}
+
+// encodeString writes a fixstr or the smallest str format.
+func (msgpackFormat) encodeString(buf *bytes.Buffer, value string) {
+	msgpackHeader(buf, len(value), 0xa0, 31, 0xd9)
+	buf.WriteString(value)
+}
+
+// arrayHeader writes a fixarray, array 16 or array 32 header.
+func (msgpackFormat) arrayHeader(buf *bytes.Buffer, n int) {
+	msgpackHeader(buf, n, 0x90, 15, 0xdc)
+}
+
+// mapHeader writes a fixmap, map 16 or map 32 header.
+func (msgpackFormat) mapHeader(buf *bytes.Buffer, n int) {
+	msgpackHeader(buf, n, 0x80, 15, 0xde)
+}
+
+// indefinite reports false, MessagePack requires the length of arrays and maps.
+func (msgpackFormat) indefinite() bool {
+	return false
+}
+
+// end does nothing, as MessagePack has no values of unknown length.
+func (msgpackFormat) end(*bytes.Buffer) {}
+
+// msgpackHeader writes the header of a string, array or map of length n. Lengths up to fixMax are stored in
+// the fix byte, longer lengths use the formats starting at first. Strings have an additional 8 bit format
+// before the 16 and 32 bit formats.
+func msgpackHeader(buf *bytes.Buffer, n int, fix byte, fixMax int, first byte) {
+	if n <= fixMax {
+		buf.WriteByte(fix | byte(n))
+		return
+	}
+	if first == 0xd9 {
+		if n <= math.MaxUint8 {
+			buf.Write([]byte{first, byte(n)})
+			return
+		}
+		first++
+	}
+	if n <= math.MaxUint16 {
+		buf.WriteByte(first)
+		buf.Write(binary.BigEndian.AppendUint16(nil, uint16(n)))
+		return
+	}
+	buf.WriteByte(first + 1)
+	buf.Write(binary.BigEndian.AppendUint32(nil, uint32(n)))
+}
@@ -0,0 +1,52 @@
+package csv2json
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// TestMsgpackFormat tests the MessagePack encoding of single values.
+func TestMsgpackFormat(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+		want  string
+	}{
+		{name: "nil", value: nil, want: "c0"},
+		{name: "false", value: false, want: "c2"},
+		{name: "true", value: true, want: "c3"},
+		{name: "positive fixint", value: 127, want: "7f"},
+		{name: "uint 8", value: 128, want: "cc80"},
+		{name: "uint 16", value: 256, want: "cd0100"},
+		{name: "uint 32", value: 65536, want: "ce00010000"},
+		{name: "uint 64", value: int64(1) << 32, want: "cf0000000100000000"},
+		{name: "negative fixint", value: -1, want: "ff"},
+		{name: "int 8", value: -33, want: "d0df"},
+		{name: "int 16", value: -129, want: "d1ff7f"},
+		{name: "int 32", value: -32769, want: "d2ffff7fff"},
+		{name: "float 64", value: 1.5, want: "cb3ff8000000000000"},
+		{name: "float 32", value: float32(1.5), want: "ca3fc00000"},
+		{name: "fixstr", value: "a", want: "a161"},
+		{name: "str 8", value: strings.Repeat("a", 32), want: "d920" + strings.Repeat("61", 32)},
+		{name: "str 16", value: strings.Repeat("a", 256), want: "da0100" + strings.Repeat("61", 256)},
+		{name: "fixarray", value: []any{1, "a"}, want: "9201a161"},
+		{name: "array 16", value: make([]any, 16), want: "dc0010" + strings.Repeat("c0", 16)},
+		{name: "fixmap", value: map[string]any{"b": 2, "a": 1}, want: "82a16101a16202"},
+		{name: "ordered map", value: orderedMap{keys: []string{"b", "a"}, values: map[string]any{"a": 1, "b": 2}}, want: "82a16202a16101"},
+		{name: "strings", value: []string{"a"}, want: "91a161"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := encodeBinary(msgpackFormat{}, &buf, tt.value); err != nil {
+				t.Fatalf("encodeBinary() error = %v", err)
+			}
+			if got := hex.EncodeToString(buf.Bytes()); got != tt.want {
+				t.Errorf("encodeBinary() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
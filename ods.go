@@ -0,0 +1,311 @@
+package csv2json
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// odsDuration matches the ISO 8601 durations used for time cells, e.g. PT12H30M00S
+var odsDuration = regexp.MustCompile(`^PT(\d+)H(\d+)M(\d+(?:\.\d+)?)S$`)
+
+// odsReader reads the rows of a single sheet of an OpenDocument spreadsheet. The content is streamed,
+// empty rows are skipped and trailing empty cells are removed.
+type odsReader struct {
+	decoder *xml.Decoder
+
+	// content is closed when the reader is exhausted
+	content io.Closer
+
+	// sheet is the name or 1-based index of the sheet to read, empty for the first sheet
+	sheet string
+
+	// tables counts the sheets seen so far
+	tables int
+
+	// inSheet is set while the rows of the selected sheet are read
+	inSheet bool
+
+	// done is set after the selected sheet was read
+	done bool
+
+	// width is the number of cells of the first row, shorter rows are padded and wider rows rejected
+	width int
+
+	// rows counts the non-empty rows read so far
+	rows int
+
+	// repeated holds a row to return again for repeated rows
+	repeated []string
+
+	// repeat is the number of times repeated is still returned
+	repeat int
+}
+
+// newODSReader opens the content of an OpenDocument spreadsheet. Zip archives require random access,
+// so input not read from a file is held in memory.
+func newODSReader(in io.Reader, sheet string) (*odsReader, error) {
+	var (
+		readerAt io.ReaderAt
+		size     int64
+	)
+	if f, ok := in.(*os.File); ok && f != os.Stdin {
+		info, err := f.Stat()
+		if err != nil {
+			return nil, err
+		}
+		readerAt, size = f, info.Size()
+	} else {
+		data, err := io.ReadAll(in)
+		if err != nil {
+			return nil, err
+		}
+		readerAt, size = bytes.NewReader(data), int64(len(data))
+	}
+	archive, err := zip.NewReader(readerAt, size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open spreadsheet: %w", err)
+	}
+	content, err := archive.Open("content.xml")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open spreadsheet content: %w", err)
+	}
+	return &odsReader{decoder: xml.NewDecoder(content), content: content, sheet: sheet}, nil
+}
+
+// readHeader returns the first row of the sheet.
+func (o *odsReader) readHeader() ([]string, error) {
+	return o.read()
+}
+
+// read returns the next non-empty row of the sheet.
+func (o *odsReader) read() ([]string, error) {
+	if o.repeat > 0 {
+		o.repeat--
+		o.rows++
+		return o.repeated, nil
+	}
+	for !o.done {
+		token, err := o.decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "table":
+				if o.inSheet {
+					continue
+				}
+				o.tables++
+				if !o.selected(attribute(t, "name")) {
+					if err := o.decoder.Skip(); err != nil {
+						return nil, err
+					}
+					continue
+				}
+				o.inSheet = true
+			case "table-row":
+				if !o.inSheet {
+					continue
+				}
+				row, err := o.readRow()
+				if err != nil {
+					return nil, err
+				}
+				if len(row) == 0 {
+					continue
+				}
+				o.rows++
+				if o.width == 0 {
+					o.width = len(row)
+				} else if len(row) > o.width {
+					return nil, fmt.Errorf("row %d: %w", o.rows, csv.ErrFieldCount)
+				}
+				for len(row) < o.width {
+					row = append(row, "")
+				}
+				if repeat := repetitions(t, "number-rows-repeated"); repeat > 1 {
+					o.repeated, o.repeat = row, repeat-1
+				}
+				return row, nil
+			}
+		case xml.EndElement:
+			if t.Name.Local == "table" && o.inSheet {
+				o.done = true
+			}
+		}
+	}
+	_ = o.content.Close()
+	if !o.inSheet {
+		if o.sheet == "" {
+			return nil, errors.New("spreadsheet does not contain a sheet")
+		}
+		return nil, fmt.Errorf("sheet %q not found", o.sheet)
+	}
+	return nil, io.EOF
+}
+
+// selected reports whether the current sheet with the given name is the one to read.
+func (o *odsReader) selected(name string) bool {
+	if o.sheet == "" {
+		return o.tables == 1
+	}
+	if name == o.sheet {
+		return true
+	}
+	index, err := strconv.Atoi(o.sheet)
+	return err == nil && index == o.tables
+}
+
+// readRow reads the cells of a row until its end element. Trailing empty cells are dropped.
+func (o *odsReader) readRow() ([]string, error) {
+	var (
+		row   []string
+		empty int
+	)
+	for {
+		token, err := o.decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Local != "table-cell" && t.Name.Local != "covered-table-cell" {
+				continue
+			}
+			value, err := o.readCell(t)
+			if err != nil {
+				return nil, err
+			}
+			repeat := repetitions(t, "number-columns-repeated")
+			if value == "" {
+				// empty cells are only added when followed by a non-empty cell
+				empty += repeat
+				continue
+			}
+			for ; empty > 0; empty-- {
+				row = append(row, "")
+			}
+			for i := 0; i < repeat; i++ {
+				row = append(row, value)
+			}
+		case xml.EndElement:
+			if t.Name.Local == "table-row" {
+				return row, nil
+			}
+		}
+	}
+}
+
+// readCell returns the value of a cell. Typed cells use the value of their type attribute, all other cells
+// their text content.
+func (o *odsReader) readCell(cell xml.StartElement) (string, error) {
+	text, err := o.readText(cell.Name.Local)
+	if err != nil {
+		return "", err
+	}
+	switch attribute(cell, "value-type") {
+	case "float", "percentage", "currency":
+		return attribute(cell, "value"), nil
+	case "date":
+		return attribute(cell, "date-value"), nil
+	case "time":
+		value := attribute(cell, "time-value")
+		if match := odsDuration.FindStringSubmatch(value); match != nil {
+			hours, _ := strconv.Atoi(match[1])
+			minutes, _ := strconv.Atoi(match[2])
+			seconds, _ := strconv.ParseFloat(match[3], 64)
+			return fmt.Sprintf("%02d:%02d:%02d", hours, minutes, int(seconds)), nil
+		}
+		return value, nil
+	case "boolean":
+		return attribute(cell, "boolean-value"), nil
+	case "string":
+		if value, ok := attributeValue(cell, "string-value"); ok {
+			return value, nil
+		}
+	}
+	return text, nil
+}
+
+// readText collects the text content of an element until its end element. Paragraphs are separated by
+// a newline, spaces, tabs and line breaks are expanded.
+func (o *odsReader) readText(element string) (string, error) {
+	var (
+		text       strings.Builder
+		paragraphs int
+		depth      int
+	)
+	for {
+		token, err := o.decoder.Token()
+		if err != nil {
+			return "", err
+		}
+		switch t := token.(type) {
+		case xml.CharData:
+			text.Write(t)
+		case xml.StartElement:
+			depth++
+			switch t.Name.Local {
+			case "p":
+				if paragraphs > 0 {
+					text.WriteByte('\n')
+				}
+				paragraphs++
+			case "s":
+				text.WriteString(strings.Repeat(" ", repetitions(t, "c")))
+			case "tab":
+				text.WriteByte('\t')
+			case "line-break":
+				text.WriteByte('\n')
+			case "annotation":
+				// comments are not part of the value
+				if err := o.decoder.Skip(); err != nil {
+					return "", err
+				}
+				depth--
+			}
+		case xml.EndElement:
+			if depth == 0 && t.Name.Local == element {
+				return text.String(), nil
+			}
+			depth--
+		}
+	}
+}
+
+// attribute returns the value of the attribute with the given local name.
+func attribute(element xml.StartElement, name string) string {
+	value, _ := attributeValue(element, name)
+	return value
+}
+
+// attributeValue returns the value of the attribute with the given local name and whether it exists.
+func attributeValue(element xml.StartElement, name string) (string, bool) {
+	for _, attr := range element.Attr {
+		if attr.Name.Local == name {
+			return attr.Value, true
+		}
+	}
+	return "", false
+}
+
+// repetitions returns the numeric value of a repetition attribute, defaulting to 1.
+func repetitions(element xml.StartElement, name string) int {
+	if n, err := strconv.Atoi(attribute(element, name)); err == nil && n > 0 {
+		return n
+	}
+	return 1
+}
@@ -0,0 +1,278 @@
+package csv2json
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// odsContent is a spreadsheet with two sheets, the second one using typed, repeated and covered cells.
+const odsContent = `<?xml version="1.0" encoding="UTF-8"?>
+<office:document-content
+	xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0"
+	xmlns:table="urn:oasis:names:tc:opendocument:xmlns:table:1.0"
+	xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0">
+<office:body><office:spreadsheet>
+<table:table table:name="Notes">
+	<table:table-row><table:table-cell office:value-type="string"><text:p>ignored</text:p></table:table-cell></table:table-row>
+</table:table>
+<table:table table:name="Data">
+	<table:table-column table:number-columns-repeated="4"/>
+	<table:table-row>
+		<table:table-cell office:value-type="string"><text:p>id</text:p></table:table-cell>
+		<table:table-cell office:value-type="string"><text:p>name</text:p></table:table-cell>
+		<table:table-cell office:value-type="string"><text:p>price</text:p></table:table-cell>
+		<table:table-cell office:value-type="string"><text:p>date</text:p></table:table-cell>
+		<table:table-cell office:value-type="string"><text:p>active</text:p></table:table-cell>
+		<table:table-cell table:number-columns-repeated="1019"/>
+	</table:table-row>
+	<table:table-row>
+		<table:table-cell office:value-type="float" office:value="1"><text:p>1</text:p></table:table-cell>
+		<table:table-cell office:value-type="string"><text:p>Big<text:s text:c="2"/>box</text:p><text:p>second line</text:p></table:table-cell>
+		<table:table-cell office:value-type="currency" office:currency="EUR" office:value="1234.5"><text:p>1.234,50 €</text:p></table:table-cell>
+		<table:table-cell office:value-type="date" office:date-value="2026-10-01"><text:p>01.10.26</text:p></table:table-cell>
+		<table:table-cell office:value-type="boolean" office:boolean-value="true"><text:p>TRUE</text:p></table:table-cell>
+	</table:table-row>
+	<table:table-row table:number-rows-repeated="2">
+		<table:table-cell office:value-type="float" office:value="2"><text:p>2</text:p></table:table-cell>
+		<table:table-cell table:number-columns-repeated="2"/>
+		<table:table-cell office:value-type="time" office:time-value="PT13H05M09S"><text:p>13:05</text:p></table:table-cell>
+	</table:table-row>
+	<table:table-row table:number-rows-repeated="1048570">
+		<table:table-cell table:number-columns-repeated="1024"/>
+	</table:table-row>
+</table:table>
+</office:spreadsheet></office:body>
+</office:document-content>`
+
+// writeODS writes a spreadsheet archive with the given content to path.
+func writeODS(t *testing.T, path, content string) {
+	t.Helper()
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for name, data := range map[string]string{"mimetype": "application/vnd.oasis.opendocument.spreadsheet", "content.xml": content} {
+		w, err := archive.Create(name)
+		if err != nil {
+			t.Fatalf("Failed to create archive entry: %v", err)
+		}
+		if _, err := w.Write([]byte(data)); err != nil {
+			t.Fatalf("Failed to write archive entry: %v", err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatalf("Failed to close archive: %v", err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0600); err != nil {
+		t.Fatalf("Failed to write spreadsheet: %v", err)
+	}
+}
+
+// TestODSReader tests reading rows of a sheet selected by name or index.
+func TestODSReader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "input.ods")
+	writeODS(t, path, odsContent)
+
+	data := [][]string{
+		{"id", "name", "price", "date", "active"},
+		{"1", "Big  box\nsecond line", "1234.5", "2026-10-01", "true"},
+		{"2", "", "", "13:05:09", ""},
+		{"2", "", "", "13:05:09", ""},
+	}
+	tests := []struct {
+		name    string
+		sheet   string
+		want    [][]string
+		wantErr bool
+	}{
+		{
+			name:  "first sheet",
+			sheet: "",
+			want:  [][]string{{"ignored"}},
+		},
+		{
+			name:  "sheet by name",
+			sheet: "Data",
+			want:  data,
+		},
+		{
+			name:  "sheet by index",
+			sheet: "2",
+			want:  data,
+		},
+		{
+			name:    "missing sheet",
+			sheet:   "Other",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := os.Open(path)
+			if err != nil {
+				t.Fatalf("Failed to open spreadsheet: %v", err)
+			}
+			defer f.Close()
+			reader, err := newODSReader(f, tt.sheet)
+			if err != nil {
+				t.Fatalf("newODSReader() error = %v", err)
+			}
+			var got [][]string
+			for {
+				row, err := reader.read()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					if !tt.wantErr {
+						t.Fatalf("read() error = %v", err)
+					}
+					return
+				}
+				got = append(got, row)
+			}
+			if tt.wantErr {
+				t.Fatalf("read() error = nil, want error")
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("read() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestODSReaderWideRow tests rejecting rows with more cells than the first row.
+func TestODSReaderWideRow(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "input.ods")
+	writeODS(t, path, `<?xml version="1.0" encoding="UTF-8"?>
+<office:document-content
+	xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0"
+	xmlns:table="urn:oasis:names:tc:opendocument:xmlns:table:1.0"
+	xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0">
+<office:body><office:spreadsheet>
+<table:table table:name="Data">
+	<table:table-row>
+		<table:table-cell><text:p>id</text:p></table:table-cell>
+		<table:table-cell><text:p>name</text:p></table:table-cell>
+	</table:table-row>
+	<table:table-row>
+		<table:table-cell><text:p>1</text:p></table:table-cell>
+		<table:table-cell><text:p>Jane</text:p></table:table-cell>
+		<table:table-cell table:number-columns-repeated="3"/>
+	</table:table-row>
+	<table:table-row>
+		<table:table-cell><text:p>2</text:p></table:table-cell>
+		<table:table-cell><text:p>Joe</text:p></table:table-cell>
+		<table:table-cell><text:p>extra</text:p></table:table-cell>
+	</table:table-row>
+</table:table>
+</office:spreadsheet></office:body>
+</office:document-content>`)
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open spreadsheet: %v", err)
+	}
+	defer f.Close()
+	reader, err := newODSReader(f, "")
+	if err != nil {
+		t.Fatalf("newODSReader() error = %v", err)
+	}
+	for _, want := range [][]string{{"id", "name"}, {"1", "Jane"}} {
+		row, err := reader.read()
+		if err != nil {
+			t.Fatalf("read() error = %v", err)
+		}
+		if !reflect.DeepEqual(row, want) {
+			t.Errorf("read() = %q, want %q", row, want)
+		}
+	}
+	if _, err := reader.read(); !errors.Is(err, csv.ErrFieldCount) {
+		t.Errorf("read() error = %v, want %v", err, csv.ErrFieldCount)
+	}
+}
+
+// TestMapODSInput tests the Map method on named spreadsheet input with a preamble row.
+func TestMapODSInput(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "input.ods")
+	writeODS(t, in, odsContent)
+	mappingFile := filepath.Join(dir, "mapping.json")
+	mappingJSON := `{
+		"mapping": {
+			"id": {"property": "id", "type": "int"},
+			"price": {"property": "price", "type": "float"},
+			"active": {"property": "active", "type": "string"}
+		}
+	}`
+	if err := os.WriteFile(mappingFile, []byte(mappingJSON), 0600); err != nil {
+		t.Fatalf("Failed to write mapping file: %v", err)
+	}
+	out := filepath.Join(dir, "out.json")
+
+	mapper, err := NewMapper(
+		WithIn(in),
+		WithOut(out),
+		WithNamed(true),
+		WithArray(true),
+		WithMappingFile(mappingFile),
+		WithOutputType("json"),
+		WithInputType("ods"),
+		WithSheet("Data"),
+		WithSkipTrailer(2),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create mapper: %v", err)
+	}
+	if err := mapper.Map(); err != nil {
+		t.Fatalf("Failed to map: %v", err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("Failed to read output file: %v", err)
+	}
+	var result []map[string]any
+	if err := json.Unmarshal(data, &result); err != nil {
+		t.Fatalf("Failed to parse output JSON: %v", err)
+	}
+	want := []map[string]any{{"id": float64(1), "price": 1234.5, "active": "true"}}
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("Map() = %v, want %v", result, want)
+	}
+}
+
+// TestSkipPreambleRows tests skipping leading rows of spreadsheet input.
+func TestSkipPreambleRows(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "input.ods")
+	writeODS(t, path, odsContent)
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open spreadsheet: %v", err)
+	}
+	defer f.Close()
+	reader, err := newODSReader(f, "Data")
+	if err != nil {
+		t.Fatalf("newODSReader() error = %v", err)
+	}
+	m := &Mapper{separator: ',', skipLines: 1}
+	rows, preamble, err := m.skipPreambleRows(reader)
+	if err != nil {
+		t.Fatalf("skipPreambleRows() error = %v", err)
+	}
+	if want := []string{"id,name,price,date,active"}; !reflect.DeepEqual(preamble, want) {
+		t.Errorf("skipPreambleRows() preamble = %q, want %q", preamble, want)
+	}
+	header, err := rows.readHeader()
+	if err != nil {
+		t.Fatalf("readHeader() error = %v", err)
+	}
+	if header[0] != "1" {
+		t.Errorf("readHeader() = %q, want the first row after the preamble", header)
+	}
+}
@@ -0,0 +1,277 @@
+package csv2json
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// orderedMap is an object whose properties are written in the order of keys.
+type orderedMap struct {
+	keys   []string
+	values map[string]any
+}
+
+// MarshalYAML returns a mapping node with the properties in order.
+func (o orderedMap) MarshalYAML() (any, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	for _, key := range o.keys {
+		var value yaml.Node
+		if err := value.Encode(o.values[key]); err != nil {
+			return nil, err
+		}
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, &value)
+	}
+	return node, nil
+}
+
+// UnmarshalJSON parses the configuration and records the order of the mapping keys.
+func (c *Configuration) UnmarshalJSON(data []byte) error {
+	type configuration Configuration
+	if err := json.Unmarshal(data, (*configuration)(c)); err != nil {
+		return err
+	}
+	var raw struct {
+		Mapping json.RawMessage `json:"mapping"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	keys, err := objectKeys(raw.Mapping)
+	c.mappingKeys = keys
+	return err
+}
+
+// UnmarshalJSON parses the record types and records the order in which they are declared.
+func (r *RecordTypesConfiguration) UnmarshalJSON(data []byte) error {
+	type recordTypes RecordTypesConfiguration
+	if err := json.Unmarshal(data, (*recordTypes)(r)); err != nil {
+		return err
+	}
+	var raw struct {
+		Types json.RawMessage `json:"types"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	keys, err := objectKeys(raw.Types)
+	r.typeNames = keys
+	return err
+}
+
+// UnmarshalJSON parses the record type and records the order of the mapping keys.
+func (r *RecordType) UnmarshalJSON(data []byte) error {
+	type recordType RecordType
+	if err := json.Unmarshal(data, (*recordType)(r)); err != nil {
+		return err
+	}
+	var raw struct {
+		Mapping json.RawMessage `json:"mapping"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	keys, err := objectKeys(raw.Mapping)
+	r.mappingKeys = keys
+	return err
+}
+
+// objectKeys returns the keys of a JSON object in the order they appear. Empty input and null yield no keys.
+func objectKeys(data json.RawMessage) ([]string, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || data[0] != '{' {
+		return nil, nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	// opening brace
+	if _, err := decoder.Token(); err != nil {
+		return nil, err
+	}
+	var keys []string
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, token.(string))
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
+
+// declaredKeys returns the keys of mapping in the order of the mapping file. Keys not known from the file,
+// e.g. for configurations created in code, follow in sorted order.
+func declaredKeys(mapping map[string]ColumnConfiguration, keys []string) []string {
+	result := make([]string, 0, len(mapping))
+	for _, key := range keys {
+		if _, ok := mapping[key]; ok {
+			result = append(result, key)
+		}
+	}
+	var rest []string
+	for key := range mapping {
+		if !slices.Contains(result, key) {
+			rest = append(rest, key)
+		}
+	}
+	slices.Sort(rest)
+	return append(result, rest...)
+}
+
+// order returns value with its properties in the given order if ordered output is requested by the flag or an
+// explicit order in the mapping.
+func (m *Mapper) order(value any, order []string) any {
+	if !m.ordered && len(m.configuration.Order) == 0 {
+		return value
+	}
+	return orderValue(value, order)
+}
+
+// propertyOrder returns the declared order of record properties and of the properties of the document written
+// for nested output. Records follow the explicit order, then the mapping, the record level calculated fields and
+// the mappings and calculated fields of the record types. The document starts with the records, followed by rows
+// of record types located in the document and the document level calculated fields.
+func (m *Mapper) propertyOrder() ([]string, []string) {
+	var records, document []string
+	addFields := func(order []string, mapping map[string]ColumnConfiguration, keys []string, calculated []CalculatedField, prefix string) []string {
+		for _, key := range declaredKeys(mapping, keys) {
+			order = append(order, prefix+mapping[key].Property)
+		}
+		for _, field := range calculated {
+			if field.Location == "record" {
+				order = append(order, prefix+field.Property)
+			}
+		}
+		return order
+	}
+
+	records = append(records, m.configuration.Order...)
+	records = addFields(records, m.configuration.Mapping, m.configuration.mappingKeys, m.configuration.Calculated, "")
+	types := m.configuration.RecordTypes
+	names := slices.Clone(types.typeNames)
+	for name := range types.Types {
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names[len(types.typeNames):])
+	for _, name := range names {
+		recordType, ok := types.Types[name]
+		if !ok {
+			continue
+		}
+		if recordType.Location == "document" {
+			document = append(document, recordType.Property)
+			document = addFields(document, recordType.Mapping, recordType.mappingKeys, recordType.Calculated, recordType.Property+".")
+			continue
+		}
+		records = addFields(records, recordType.Mapping, recordType.mappingKeys, recordType.Calculated, "")
+	}
+
+	// the records are the first property of the document, so they can be written as they are produced
+	propertyName := m.documentProperty()
+	if propertyName == "" {
+		propertyName = "data"
+	}
+	document = append([]string{propertyName}, document...)
+	for _, property := range records {
+		document = append(document, propertyName+"."+property)
+	}
+	for _, field := range m.configuration.Calculated {
+		if field.Location == "document" {
+			document = append(document, field.Property)
+		}
+	}
+	return records, document
+}
+
+// orderValue converts objects within value to ordered maps. Properties listed in order, using dotted names for
+// nested properties, come first, all others follow in sorted order. Elements of arrays share the order of the array.
+func orderValue(value any, order []string) any {
+	switch v := value.(type) {
+	case map[string]any:
+		result := orderedMap{keys: make([]string, 0, len(v)), values: make(map[string]any, len(v))}
+		for _, property := range order {
+			key, _, _ := strings.Cut(property, ".")
+			if _, ok := v[key]; ok && !slices.Contains(result.keys, key) {
+				result.keys = append(result.keys, key)
+			}
+		}
+		var rest []string
+		for key := range v {
+			if !slices.Contains(result.keys, key) {
+				rest = append(rest, key)
+			}
+		}
+		slices.Sort(rest)
+		result.keys = append(result.keys, rest...)
+		for _, key := range result.keys {
+			var children []string
+			for _, property := range order {
+				if child, ok := strings.CutPrefix(property, key+"."); ok {
+					children = append(children, child)
+				}
+			}
+			result.values[key] = orderValue(v[key], children)
+		}
+		return result
+	case []map[string]any:
+		result := make([]any, len(v))
+		for i := range v {
+			result[i] = orderValue(v[i], order)
+		}
+		return result
+	case []any:
+		result := make([]any, len(v))
+		for i := range v {
+			result[i] = orderValue(v[i], order)
+		}
+		return result
+	}
+	return value
+}
+
+// orderedStructs converts ordered maps within value to structs, as TOML keeps the order of struct fields only.
+// Keys that can not be used as a struct tag keep their map and thus sorted order.
+func orderedStructs(value any) any {
+	switch v := value.(type) {
+	case orderedMap:
+		fields := make([]reflect.StructField, len(v.keys))
+		for i, key := range v.keys {
+			if strings.ContainsAny(key, ",\"`") {
+				values := make(map[string]any, len(v.values))
+				for k, val := range v.values {
+					values[k] = orderedStructs(val)
+				}
+				return values
+			}
+			fields[i] = reflect.StructField{
+				Name: "F" + strconv.Itoa(i),
+				Type: reflect.TypeFor[any](),
+				Tag:  reflect.StructTag(`toml:` + strconv.Quote(key)),
+			}
+		}
+		result := reflect.New(reflect.StructOf(fields)).Elem()
+		for i, key := range v.keys {
+			if val := orderedStructs(v.values[key]); val != nil {
+				result.Field(i).Set(reflect.ValueOf(val))
+			}
+		}
+		return result.Interface()
+	case []any:
+		result := make([]any, len(v))
+		for i := range v {
+			result[i] = orderedStructs(v[i])
+		}
+		return result
+	}
+	return value
+}
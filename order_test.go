@@ -0,0 +1,119 @@
+package csv2json
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// TestConfigurationKeyOrder tests that parsing a mapping file keeps the order of mapping keys and record types.
+func TestConfigurationKeyOrder(t *testing.T) {
+	data := `{
+		"mapping": {"z": {"property": "z"}, "a": {"property": "a"}, "m": {"property": "m"}},
+		"record_types": {
+			"types": {
+				"T": {"mapping": {"2": {"property": "b"}, "1": {"property": "a"}}},
+				"H": {"mapping": {}}
+			}
+		}
+	}`
+	var configuration Configuration
+	if err := json.Unmarshal([]byte(data), &configuration); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if want := []string{"z", "a", "m"}; !reflect.DeepEqual(configuration.mappingKeys, want) {
+		t.Errorf("mappingKeys = %v, want %v", configuration.mappingKeys, want)
+	}
+	if want := []string{"T", "H"}; !reflect.DeepEqual(configuration.RecordTypes.typeNames, want) {
+		t.Errorf("typeNames = %v, want %v", configuration.RecordTypes.typeNames, want)
+	}
+	if want := []string{"2", "1"}; !reflect.DeepEqual(configuration.RecordTypes.Types["T"].mappingKeys, want) {
+		t.Errorf("record type mappingKeys = %v, want %v", configuration.RecordTypes.Types["T"].mappingKeys, want)
+	}
+	if len(configuration.Mapping) != 3 || configuration.Mapping["m"].Property != "m" {
+		t.Errorf("Mapping = %v, want all columns", configuration.Mapping)
+	}
+}
+
+// TestMapOrdered tests that ordered output follows the declared order for all output types.
+func TestMapOrdered(t *testing.T) {
+	mappingJSON := `{
+		"mapping": {
+			"0": {"property": "id", "type": "int"},
+			"1": {"property": "name.last", "type": "string"},
+			"2": {"property": "name.first", "type": "string"}
+		},
+		"calculated": [
+			{"property": "calculated", "kind": "extra", "format": "x", "type": "string", "location": "record"},
+			{"property": "count", "kind": "application", "format": "records", "type": "int", "location": "document"}
+		],
+		"extra_variables": {"x": {"value": "yes"}}
+		%s
+	}`
+
+	tests := []struct {
+		name       string
+		order      string
+		ordered    bool
+		outputType string
+		array      bool
+		nested     string
+		want       string
+	}{
+		{
+			name:       "sorted by default",
+			outputType: "json",
+			want:       `{"calculated":"yes","id":1,"name":{"first":"Jane","last":"Doe"}}`,
+		},
+		{
+			name:       "declared order",
+			ordered:    true,
+			outputType: "json",
+			want:       `{"id":1,"name":{"last":"Doe","first":"Jane"},"calculated":"yes"}`,
+		},
+		{
+			name:       "explicit order",
+			order:      `, "order": ["calculated", "name.first"]`,
+			outputType: "json",
+			want:       `{"calculated":"yes","name":{"first":"Jane","last":"Doe"},"id":1}`,
+		},
+		{
+			name:       "nested array",
+			ordered:    true,
+			outputType: "json",
+			array:      true,
+			nested:     "items",
+			want:       `{"items":[{"id":1,"name":{"last":"Doe","first":"Jane"},"calculated":"yes"}],"count":1}`,
+		},
+		{
+			name:       "yaml",
+			ordered:    true,
+			outputType: "yaml",
+			want:       "- id: 1\n  name:\n    last: Doe\n    first: Jane\n  calculated: \"yes\"\n",
+		},
+		{
+			name:       "toml",
+			ordered:    true,
+			outputType: "toml",
+			want:       "count = 1\n\n[[data]]\n  id = 1\n  calculated = \"yes\"\n  [data.name]\n    last = \"Doe\"\n    first = \"Jane\"\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := mapToFile(t, fmt.Sprintf(mappingJSON, tt.order), "1,Doe,Jane\n",
+				WithArray(tt.array),
+				WithOutputType(tt.outputType),
+				WithNestedPropertyName(tt.nested),
+				WithOrdered(tt.ordered),
+			)
+			if err != nil {
+				t.Fatalf("Failed to map: %v", err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("Map() = %q, want %q", data, tt.want)
+			}
+		})
+	}
+}
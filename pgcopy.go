@@ -0,0 +1,162 @@
+package csv2json
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// pgCopySignature starts every file in the binary COPY format
+var pgCopySignature = []byte("PGCOPY\n\377\r\n\000")
+
+// pgCopyWriter writes records in the text or binary format of PostgreSQL COPY FROM STDIN. Columns follow the
+// declared order of the mapping, nested properties are flattened.
+type pgCopyWriter struct {
+	mapper *Mapper
+	out    io.Writer
+
+	// columns holds the dotted property paths written as columns
+	columns []string
+
+	// types holds the mapping type of every column
+	types []string
+
+	buf bytes.Buffer
+}
+
+// newPGCopyWriter creates a pgCopyWriter and writes the header of the binary format.
+func (m *Mapper) newPGCopyWriter(out io.Writer, recordOrder []string) (*pgCopyWriter, error) {
+	w := &pgCopyWriter{mapper: m, out: out, columns: csvColumns(recordOrder)}
+	types := m.propertyTypes()
+	for _, column := range w.columns {
+		w.types = append(w.types, types[column])
+	}
+	if m.copyFormat == "binary" {
+		w.buf.Write(pgCopySignature)
+		// flags and length of the header extension
+		_ = binary.Write(&w.buf, binary.BigEndian, [2]int32{0, 0})
+		if _, err := out.Write(w.buf.Bytes()); err != nil {
+			return nil, err
+		}
+	}
+	return w, nil
+}
+
+// write writes the record as a row.
+func (w *pgCopyWriter) write(record map[string]any) error {
+	values := make(map[string]any)
+	flattenRecord(record, "", values)
+	w.buf.Reset()
+	if w.mapper.copyFormat == "binary" {
+		_ = binary.Write(&w.buf, binary.BigEndian, int16(len(w.columns)))
+	}
+	for i, column := range w.columns {
+		var err error
+		if w.mapper.copyFormat == "binary" {
+			err = w.binaryField(values[column], w.types[i])
+		} else {
+			if i > 0 {
+				w.buf.WriteByte('\t')
+			}
+			err = w.textField(values[column])
+		}
+		if err != nil {
+			return fmt.Errorf("column %q: %w", column, err)
+		}
+	}
+	if w.mapper.copyFormat != "binary" {
+		w.buf.WriteByte('\n')
+	}
+	_, err := w.out.Write(w.buf.Bytes())
+	return err
+}
+
+// close writes the trailer of the binary format.
+func (w *pgCopyWriter) close(map[string]any) error {
+	if w.mapper.copyFormat != "binary" {
+		return nil
+	}
+	return binary.Write(w.out, binary.BigEndian, int16(-1))
+}
+
+// textField writes a value in the text format. Missing values are written as \N, backslashes and control
+// characters are escaped.
+func (w *pgCopyWriter) textField(value any) error {
+	if value == nil {
+		w.buf.WriteString(`\N`)
+		return nil
+	}
+	text, err := w.text(value)
+	if err != nil {
+		return err
+	}
+	w.buf.WriteString(pgCopyEscaper.Replace(text))
+	return nil
+}
+
+// pgCopyEscaper escapes the characters with a special meaning in the text format
+var pgCopyEscaper = strings.NewReplacer(`\`, `\\`, "\b", `\b`, "\f", `\f`, "\n", `\n`, "\r", `\r`, "\t", `\t`, "\v", `\v`)
+
+// binaryField writes a value in the binary format: the length of the value followed by its bytes, -1 for
+// missing values. int columns are written as bigint, float columns as double precision, bool columns as
+// boolean and all other columns as text.
+func (w *pgCopyWriter) binaryField(value any, columnType string) error {
+	if value == nil {
+		return binary.Write(&w.buf, binary.BigEndian, int32(-1))
+	}
+	var data []byte
+	switch columnType {
+	case "int":
+		v, ok := value.(int)
+		if !ok {
+			return fmt.Errorf("expected an int value, found %T", value)
+		}
+		data = binary.BigEndian.AppendUint64(nil, uint64(int64(v)))
+	case "float":
+		v, ok := value.(float64)
+		if !ok {
+			return fmt.Errorf("expected a float value, found %T", value)
+		}
+		data = binary.BigEndian.AppendUint64(nil, math.Float64bits(v))
+	case "bool":
+		v, ok := value.(bool)
+		if !ok {
+			return fmt.Errorf("expected a bool value, found %T", value)
+		}
+		data = []byte{0}
+		if v {
+			data[0] = 1
+		}
+	default:
+		text, err := w.text(value)
+		if err != nil {
+			return err
+		}
+		data = []byte(text)
+	}
+	_ = binary.Write(&w.buf, binary.BigEndian, int32(len(data)))
+	w.buf.Write(data)
+	return nil
+}
+
+// text returns the text representation of a value. Objects and arrays are written as JSON.
+func (w *pgCopyWriter) text(value any) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case float64:
+		var buf bytes.Buffer
+		err := w.mapper.encoder.encodeFloat(&buf, v, 64)
+		return buf.String(), err
+	}
+	d, err := w.mapper.encoder.marshal(value)
+	return string(d), err
+}
@@ -0,0 +1,133 @@
+package csv2json
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+// TestMapPGCopyOutput tests writing records in the text and binary COPY formats.
+func TestMapPGCopyOutput(t *testing.T) {
+	mappingJSON := `{
+		"mapping": {
+			"0": {"property": "id", "type": "int"},
+			"1": {"property": "name.first", "type": "string"},
+			"2": {"property": "price", "type": "float"},
+			"3": {"property": "active", "type": "bool"}
+		}
+	}`
+	input := "1,\"a\tb\\c\nd\",1.5,true\n"
+
+	var binaryWant bytes.Buffer
+	binaryWant.Write(pgCopySignature)
+	binaryWant.Write([]byte{0, 0, 0, 0, 0, 0, 0, 0})
+	binaryWant.Write([]byte{0, 4})
+	binaryWant.Write([]byte{0, 0, 0, 8, 0, 0, 0, 0, 0, 0, 0, 1})
+	binaryWant.Write([]byte{0, 0, 0, 7})
+	binaryWant.WriteString("a\tb\\c\nd")
+	binaryWant.Write([]byte{0, 0, 0, 8})
+	binaryWant.Write(binary.BigEndian.AppendUint64(nil, math.Float64bits(1.5)))
+	binaryWant.Write([]byte{0, 0, 0, 1, 1})
+	binaryWant.Write([]byte{0xff, 0xff})
+
+	tests := []struct {
+		name    string
+		options []OptionFunc
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "text",
+			want: "1\ta\\tb\\\\c\\nd\t1.5\ttrue\n",
+		},
+		{
+			name:    "binary",
+			options: []OptionFunc{WithCopyFormat("binary")},
+			want:    binaryWant.String(),
+		},
+		{
+			name:    "unknown format",
+			options: []OptionFunc{WithCopyFormat("csv")},
+			wantErr: true,
+		},
+		{
+			name:    "nested",
+			options: []OptionFunc{WithNestedPropertyName("records")},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := mapToFile(t, mappingJSON, input, append([]OptionFunc{WithOutputType("pgcopy")}, tt.options...)...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Map() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if string(data) != tt.want {
+				t.Errorf("Map() = %q, want %q", data, tt.want)
+			}
+		})
+	}
+}
+
+// TestPGCopyWriterFields tests missing values and values not matching the column type.
+func TestPGCopyWriterFields(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		record  map[string]any
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "text null",
+			format: "text",
+			record: map[string]any{"id": 1},
+			want:   "1\t\\N\n",
+		},
+		{
+			name:   "text object",
+			format: "text",
+			record: map[string]any{"id": 1, "tags": []any{"a", "b"}},
+			want:   "1\t[\"a\",\"b\"]\n",
+		},
+		{
+			name:   "binary null",
+			format: "binary",
+			record: map[string]any{"id": 1},
+			want:   "\x00\x02\x00\x00\x00\x08\x00\x00\x00\x00\x00\x00\x00\x01\xff\xff\xff\xff",
+		},
+		{
+			name:    "binary type mismatch",
+			format:  "binary",
+			record:  map[string]any{"id": "one"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			w := &pgCopyWriter{
+				mapper:  &Mapper{copyFormat: tt.format, encoder: jsonEncoder{decimals: -1}},
+				out:     &out,
+				columns: []string{"id", "tags"},
+				types:   []string{"int", "string"},
+			}
+			err := w.write(tt.record)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("write() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if out.String() != tt.want {
+				t.Errorf("write() = %q, want %q", out.String(), tt.want)
+			}
+		})
+	}
+}
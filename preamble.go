@@ -0,0 +1,39 @@
+package csv2json
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// compilePreamble compiles the patterns of the configured preamble variables.
+func (m *Mapper) compilePreamble() error {
+	m.preamblePatterns = make([]*regexp.Regexp, len(m.configuration.Preamble))
+	for i, variable := range m.configuration.Preamble {
+		re, err := regexp.Compile(variable.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern for preamble variable %q: %w", variable.Name, err)
+		}
+		m.preamblePatterns[i] = re
+	}
+	return nil
+}
+
+// parsePreamble matches the skipped preamble lines against the configured preamble variables and stores
+// the values for preamble calculated fields. The first matching line sets the value of a variable.
+func (m *Mapper) parsePreamble(lines []string) {
+	m.preambleVariables = make(map[string]string)
+	for i, variable := range m.configuration.Preamble {
+		for _, line := range lines {
+			match := m.preamblePatterns[i].FindStringSubmatch(line)
+			if match == nil {
+				continue
+			}
+			if len(match) > 1 {
+				m.preambleVariables[variable.Name] = match[1]
+			} else {
+				m.preambleVariables[variable.Name] = match[0]
+			}
+			break
+		}
+	}
+}
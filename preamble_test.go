@@ -0,0 +1,99 @@
+package csv2json
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// TestParsePreamble tests extracting variables from preamble lines.
+func TestParsePreamble(t *testing.T) {
+	lines := []string{"Report: Sales", "Generated: 2026-10-01", "Region: EMEA / North"}
+
+	tests := []struct {
+		name      string
+		variables []PreambleVariable
+		want      map[string]string
+		wantErr   bool
+	}{
+		{
+			name: "capturing groups",
+			variables: []PreambleVariable{
+				{Name: "report", Pattern: `^Report:\s*(.*)$`},
+				{Name: "generated", Pattern: `^Generated:\s*(\S+)`},
+			},
+			want: map[string]string{"report": "Sales", "generated": "2026-10-01"},
+		},
+		{
+			name:      "whole match without group",
+			variables: []PreambleVariable{{Name: "date", Pattern: `\d{4}-\d{2}-\d{2}`}},
+			want:      map[string]string{"date": "2026-10-01"},
+		},
+		{
+			name:      "no matching line",
+			variables: []PreambleVariable{{Name: "author", Pattern: `^Author:(.*)$`}},
+			want:      map[string]string{},
+		},
+		{
+			name:      "invalid pattern",
+			variables: []PreambleVariable{{Name: "broken", Pattern: `(`}},
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Mapper{configuration: Configuration{Preamble: tt.variables}}
+			err := m.compilePreamble()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("compilePreamble() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			m.parsePreamble(lines)
+			if !reflect.DeepEqual(m.preambleVariables, tt.want) {
+				t.Errorf("parsePreamble() = %v, want %v", m.preambleVariables, tt.want)
+			}
+		})
+	}
+}
+
+// TestMapWithPreambleVariables tests preamble calculated fields at record and document level.
+func TestMapWithPreambleVariables(t *testing.T) {
+	mappingJSON := `{
+		"preamble": [
+			{"name": "report", "pattern": "^Report:\\s*(.*)$"},
+			{"name": "generated", "pattern": "^Generated:\\s*(.*)$"}
+		],
+		"mapping": {
+			"id": {"property": "id", "type": "int"}
+		},
+		"calculated": [
+			{"property": "report", "kind": "preamble", "format": "report", "type": "string", "location": "record"},
+			{"property": "_meta.report", "kind": "preamble", "format": "report", "type": "string", "location": "document"},
+			{"property": "_meta.generated", "kind": "preamble", "format": "generated", "type": "string", "location": "document"}
+		]
+	}`
+	data, err := mapToFile(t, mappingJSON, "Report: Sales\nGenerated: 2026-10-01\nid\n1\n",
+		WithNamed(true),
+		WithArray(true),
+		WithOutputType("json"),
+		WithNestedPropertyName("data"),
+		WithSkipUntil("^id$"),
+	)
+	if err != nil {
+		t.Fatalf("Failed to map: %v", err)
+	}
+	var result map[string]any
+	if err := json.Unmarshal(data, &result); err != nil {
+		t.Fatalf("Failed to parse output JSON: %v", err)
+	}
+	want := map[string]any{
+		"data":  []any{map[string]any{"id": float64(1), "report": "Sales"}},
+		"_meta": map[string]any{"report": "Sales", "generated": "2026-10-01"},
+	}
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("Map() = %v, want %v", result, want)
+	}
+}
@@ -0,0 +1,337 @@
+package csv2json
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"slices"
+	"strings"
+)
+
+// protobuf wire types
+const (
+	protoVarint  = 0
+	protoFixed64 = 1
+	protoBytes   = 2
+)
+
+// protoMaxFieldNumber is the largest field number of a protobuf message
+const protoMaxFieldNumber = 1<<29 - 1
+
+// protoField describes a field of the protobuf message derived from the mapping. Nested properties become fields
+// of a nested message type.
+type protoField struct {
+	name string
+
+	// number is the field number, explicit from the mapping or assigned in declared order
+	number int
+
+	// protoType is the scalar type of the field or message for nested properties
+	protoType string
+
+	// optional fields track presence, so zero values are written
+	optional bool
+
+	// messageName is the name of the message type
+	messageName string
+
+	// fields holds the fields of a message
+	fields []*protoField
+}
+
+// protoSchema returns the message of the records derived from the declared properties and their mapping types.
+// Fields without explicit field_number are numbered in declared order, skipping numbers in use.
+func (m *Mapper) protoSchema(recordOrder []string) (*protoField, error) {
+	if !identifier.MatchString(m.protoMessage) {
+		return nil, fmt.Errorf("%q is not a valid protobuf message name", m.protoMessage)
+	}
+	numbers, err := m.fieldNumbers()
+	if err != nil {
+		return nil, err
+	}
+	root := &protoField{protoType: "message", messageName: m.protoMessage}
+	types := m.propertyTypes()
+	optional := m.nullableProperties()
+	for _, property := range csvColumns(recordOrder) {
+		parent := root
+		path := strings.Split(property, ".")
+		for i, name := range path {
+			if !identifier.MatchString(name) {
+				return nil, fmt.Errorf("%q of property %q is not a valid protobuf field name", name, property)
+			}
+			var field *protoField
+			for _, f := range parent.fields {
+				if f.name == name {
+					field = f
+				}
+			}
+			if field == nil {
+				field = &protoField{name: name, protoType: "message", messageName: protoMessageName(name)}
+				if i == len(path)-1 {
+					field.protoType, field.optional, field.number = protoType(types[property]), optional[property], numbers[property]
+				}
+				parent.fields = append(parent.fields, field)
+			}
+			parent = field
+		}
+	}
+	if err := root.numberFields(); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+// fieldNumbers returns the explicit field numbers of the mapping, the record types and the calculated fields.
+func (m *Mapper) fieldNumbers() (map[string]int, error) {
+	numbers := make(map[string]int)
+	add := func(property string, number int) error {
+		if number == 0 {
+			return nil
+		}
+		if number < 0 || number > protoMaxFieldNumber || (number >= 19000 && number <= 19999) {
+			return fmt.Errorf("field_number %d of property %q is not a valid protobuf field number", number, property)
+		}
+		if existing, ok := numbers[property]; ok && existing != number {
+			return fmt.Errorf("property %q has the field numbers %d and %d", property, existing, number)
+		}
+		numbers[property] = number
+		return nil
+	}
+	mappings := []map[string]ColumnConfiguration{m.configuration.Mapping}
+	calculated := slices.Clone(m.configuration.Calculated)
+	for _, recordType := range m.configuration.RecordTypes.Types {
+		if recordType.Location != "document" {
+			mappings = append(mappings, recordType.Mapping)
+			calculated = append(calculated, recordType.Calculated...)
+		}
+	}
+	for _, mapping := range mappings {
+		for _, column := range mapping {
+			if err := add(column.Property, column.FieldNumber); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, field := range calculated {
+		if err := add(field.Property, field.FieldNumber); err != nil {
+			return nil, err
+		}
+	}
+	return numbers, nil
+}
+
+// numberFields checks the explicit field numbers of the message and its nested messages for duplicates and
+// assigns numbers to the remaining fields.
+func (f *protoField) numberFields() error {
+	used := make(map[int]string)
+	for _, field := range f.fields {
+		if field.number == 0 {
+			continue
+		}
+		if other, ok := used[field.number]; ok {
+			return fmt.Errorf("fields %q and %q of message %s share the field number %d", other, field.name, f.messageName, field.number)
+		}
+		used[field.number] = field.name
+	}
+	next := 1
+	for _, field := range f.fields {
+		if field.number == 0 {
+			for used[next] != "" || (next >= 19000 && next <= 19999) {
+				next++
+			}
+			field.number = next
+			used[next] = field.name
+		}
+		if field.protoType == "message" {
+			if err := field.numberFields(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// protoType returns the protobuf scalar type of a mapping type.
+func protoType(t string) string {
+	switch t {
+	case "int":
+		return "int64"
+	case "float":
+		return "double"
+	case "bool":
+		return "bool"
+	}
+	return "string"
+}
+
+// protoMessageName returns the message type name of a nested property, first_name becomes FirstName.
+func protoMessageName(name string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(name, "_") {
+		if part != "" {
+			b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+		}
+	}
+	if b.Len() == 0 {
+		return "Message"
+	}
+	return b.String()
+}
+
+// definition writes the message definition of the .proto file, nested message types are defined within their
+// parent.
+func (f *protoField) definition(b *strings.Builder, indent string) {
+	b.WriteString(indent + "message " + f.messageName + " {\n")
+	var nested []*protoField
+	for _, field := range f.fields {
+		fieldType := field.protoType
+		if field.protoType == "message" {
+			fieldType = field.messageName
+			nested = append(nested, field)
+		}
+		b.WriteString(indent + "  ")
+		if field.optional {
+			b.WriteString("optional ")
+		}
+		b.WriteString(fmt.Sprintf("%s %s = %d;\n", fieldType, field.name, field.number))
+	}
+	for _, field := range nested {
+		b.WriteString("\n")
+		field.definition(b, indent+"  ")
+	}
+	b.WriteString(indent + "}\n")
+}
+
+// protoFile returns the .proto file defining the message of the records.
+func (f *protoField) protoFile() string {
+	var b strings.Builder
+	b.WriteString("syntax = \"proto3\";\n\n")
+	f.definition(&b, "")
+	return b.String()
+}
+
+// encode writes the fields of the message. Missing values are left out, as are zero values of fields without
+// presence tracking.
+func (f *protoField) encode(buf *bytes.Buffer, record map[string]any, prefix string) error {
+	for _, field := range f.fields {
+		property := prefix + field.name
+		value := record[field.name]
+		if value == nil {
+			continue
+		}
+		switch field.protoType {
+		case "message":
+			nested, ok := value.(map[string]any)
+			if !ok {
+				return fmt.Errorf("property %q must be an object, found %T", property, value)
+			}
+			var message bytes.Buffer
+			if err := field.encode(&message, nested, property+"."); err != nil {
+				return err
+			}
+			protoTag(buf, field.number, protoBytes)
+			buf.Write(binary.AppendUvarint(nil, uint64(message.Len())))
+			buf.Write(message.Bytes())
+		case "int64":
+			v, ok := value.(int)
+			if !ok {
+				return fmt.Errorf("property %q must be an int, found %T", property, value)
+			}
+			if v != 0 || field.optional {
+				protoTag(buf, field.number, protoVarint)
+				buf.Write(binary.AppendUvarint(nil, uint64(v)))
+			}
+		case "double":
+			var v float64
+			switch n := value.(type) {
+			case float64:
+				v = n
+			case int:
+				v = float64(n)
+			default:
+				return fmt.Errorf("property %q must be a float, found %T", property, value)
+			}
+			if v != 0 || field.optional {
+				protoTag(buf, field.number, protoFixed64)
+				buf.Write(binary.LittleEndian.AppendUint64(nil, math.Float64bits(v)))
+			}
+		case "bool":
+			v, ok := value.(bool)
+			if !ok {
+				return fmt.Errorf("property %q must be a bool, found %T", property, value)
+			}
+			if v || field.optional {
+				protoTag(buf, field.number, protoVarint)
+				if v {
+					buf.WriteByte(1)
+				} else {
+					buf.WriteByte(0)
+				}
+			}
+		default:
+			text, ok := value.(string)
+			if !ok {
+				// objects and arrays are written as JSON text
+				d, err := jsonEncoder{decimals: -1}.marshal(value)
+				if err != nil {
+					return err
+				}
+				text = string(d)
+			}
+			if text != "" || field.optional {
+				protoTag(buf, field.number, protoBytes)
+				buf.Write(binary.AppendUvarint(nil, uint64(len(text))))
+				buf.WriteString(text)
+			}
+		}
+	}
+	return nil
+}
+
+// protoTag writes the key of a field: its number and wire type.
+func protoTag(buf *bytes.Buffer, number int, wireType int) {
+	buf.Write(binary.AppendUvarint(nil, uint64(number)<<3|uint64(wireType)))
+}
+
+// protobufWriter writes records as length-delimited protobuf messages: every message is preceded by its size
+// as varint.
+type protobufWriter struct {
+	out     io.Writer
+	message *protoField
+	buf     bytes.Buffer
+}
+
+// newProtobufWriter derives the message and writes the .proto file if requested.
+func (m *Mapper) newProtobufWriter(out io.Writer, recordOrder []string) (*protobufWriter, error) {
+	message, err := m.protoSchema(recordOrder)
+	if err != nil {
+		return nil, err
+	}
+	if m.protoFile != "" {
+		if err := os.WriteFile(m.protoFile, []byte(message.protoFile()), 0600); err != nil {
+			return nil, err
+		}
+	}
+	return &protobufWriter{out: out, message: message}, nil
+}
+
+// write writes the size of the record message followed by the message.
+func (w *protobufWriter) write(record map[string]any) error {
+	w.buf.Reset()
+	if err := w.message.encode(&w.buf, record, ""); err != nil {
+		return err
+	}
+	if _, err := w.out.Write(binary.AppendUvarint(nil, uint64(w.buf.Len()))); err != nil {
+		return err
+	}
+	_, err := w.out.Write(w.buf.Bytes())
+	return err
+}
+
+// close does nothing, as every message is complete.
+func (w *protobufWriter) close(map[string]any) error {
+	return nil
+}
@@ -0,0 +1,86 @@
+package csv2json
+
+import (
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestMapProtobufOutput tests writing records as length-delimited protobuf messages and the .proto definition.
+func TestMapProtobufOutput(t *testing.T) {
+	mappingJSON := `{
+		"mapping": {
+			"0": {"property": "id", "type": "int", "field_number": 5},
+			"1": {"property": "name.first", "type": "string"},
+			"2": {"property": "name.last", "type": "string", "optional": true},
+			"3": {"property": "price", "type": "float"},
+			"4": {"property": "active", "type": "bool", "field_number": 1}
+		}
+	}`
+	input := "1,Jane,Doe,1.5,true\n-2,Joe,,0,false\n"
+
+	protoFile := filepath.Join(t.TempDir(), "record.proto")
+	data, err := mapToFile(t, mappingJSON, input, WithOutputType("protobuf"), WithProtoFile(protoFile))
+	if err != nil {
+		t.Fatalf("Map() error = %v", err)
+	}
+	want := "1a" + "2801" + "120b" + "0a044a616e65" + "1203446f65" + "19000000000000f83f" + "0801" +
+		"12" + "28feffffffffffffffff01" + "1205" + "0a034a6f65"
+	if got := hex.EncodeToString(data); got != want {
+		t.Errorf("Map() = %s, want %s", got, want)
+	}
+
+	definition, err := os.ReadFile(protoFile)
+	if err != nil {
+		t.Fatalf("Failed to read proto file: %v", err)
+	}
+	wantDefinition := `syntax = "proto3";
+
+message Record {
+  int64 id = 5;
+  Name name = 2;
+  double price = 3;
+  bool active = 1;
+
+  message Name {
+    string first = 1;
+    optional string last = 2;
+  }
+}
+`
+	if string(definition) != wantDefinition {
+		t.Errorf("proto file = %s, want %s", definition, wantDefinition)
+	}
+}
+
+// TestProtoSchemaErrors tests invalid field numbers and names.
+func TestProtoSchemaErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		mapping string
+	}{
+		{name: "duplicate number", mapping: `{"mapping": {"0": {"property": "a", "field_number": 1}, "1": {"property": "b", "field_number": 1}}}`},
+		{name: "reserved number", mapping: `{"mapping": {"0": {"property": "a", "field_number": 19000}}}`},
+		{name: "negative number", mapping: `{"mapping": {"0": {"property": "a", "field_number": -1}}}`},
+		{name: "conflicting numbers", mapping: `{"mapping": {"0": {"property": "a", "field_number": 1}}, "calculated": [{"property": "a", "kind": "application", "format": "record", "type": "int", "location": "record", "field_number": 2}]}`},
+		{name: "invalid name", mapping: `{"mapping": {"0": {"property": "first-name"}}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := mapToFile(t, tt.mapping, "1\n", WithOutputType("protobuf")); err == nil {
+				t.Error("Map() expected error")
+			}
+		})
+	}
+}
+
+// TestProtoMessageName tests deriving message type names from property names.
+func TestProtoMessageName(t *testing.T) {
+	for name, want := range map[string]string{"name": "Name", "first_name": "FirstName", "_": "Message", "aB": "AB"} {
+		if got := protoMessageName(name); got != want {
+			t.Errorf("protoMessageName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package csv2json
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// recordReader provides access to the header and the records of an input source.
+type recordReader interface {
+
+	// readHeader reads the column names of the input
+	readHeader() ([]string, error)
+
+	// read returns the next record or io.EOF if there are no more records
+	read() ([]string, error)
+}
+
+// csvReader reads records from delimited input.
+type csvReader struct {
+	reader *csv.Reader
+}
+
+// readHeader reads the first row of the CSV input and enables record reuse afterward.
+func (c *csvReader) readHeader() ([]string, error) {
+	c.reader.ReuseRecord = false
+	header, err := c.reader.Read()
+	// from now on we can reuse the record
+	c.reader.ReuseRecord = true
+	return header, err
+}
+
+// read returns the next CSV record.
+func (c *csvReader) read() ([]string, error) {
+	return c.reader.Read()
+}
+
+// newRecordReader creates a recordReader for the configured input type. Preamble lines are skipped
+// before the input is parsed, rows before the header and trailer rows are dropped after parsing.
+func (m *Mapper) newRecordReader(in io.Reader) (recordReader, error) {
+	var (
+		reader   recordReader
+		preamble []string
+		err      error
+	)
+	if m.inputType == "ods" {
+		// spreadsheets are not read line by line, the preamble consists of rows
+		if reader, err = newODSReader(in, m.sheet); err != nil {
+			return nil, err
+		}
+		if reader, preamble, err = m.skipPreambleRows(reader); err != nil {
+			return nil, err
+		}
+	} else {
+		if in, preamble, err = m.skipPreamble(in); err != nil {
+			return nil, err
+		}
+		switch m.inputType {
+		case "", "csv":
+			csvIn := csv.NewReader(in)
+			csvIn.Comma = m.separator
+			csvIn.ReuseRecord = !m.named
+			if len(m.configuration.RecordTypes.Types) > 0 {
+				// every record type has its own number of columns, they are checked per type
+				csvIn.FieldsPerRecord = -1
+			}
+			reader = &csvReader{reader: csvIn}
+		case "fixed-width":
+			reader, err = newFixedWidthReader(in, m.configuration.FixedWidth)
+			if err != nil {
+				return nil, err
+			}
+		case "jsonl", "json":
+			reader = newJSONReader(in, m.inputType == "json")
+		default:
+			return nil, fmt.Errorf("unknown input type %q", m.inputType)
+		}
+	}
+	m.parsePreamble(preamble)
+	return m.filterRecords(reader), nil
+}
+
+// resolveInputs expands glob patterns in the list of inputs and verifies that all inputs exist.
+// Standard input ('-') may only be used once.
+func resolveInputs(in []string) ([]string, error) {
+	if len(in) == 0 {
+		return []string{"-"}, nil
+	}
+	result := make([]string, 0, len(in))
+	stdin := false
+	for _, i := range in {
+		if i == "-" {
+			if stdin {
+				return nil, errors.New("standard input may only be used once as input")
+			}
+			stdin = true
+			result = append(result, i)
+			continue
+		}
+		if !strings.ContainsAny(i, "*?[") {
+			if _, err := os.Stat(i); err != nil {
+				return nil, err
+			}
+			result = append(result, i)
+			continue
+		}
+		matches, err := filepath.Glob(i)
+		if err != nil {
+			return nil, fmt.Errorf("invalid input pattern %q: %w", i, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no input files match %q", i)
+		}
+		result = append(result, matches...)
+	}
+	return result, nil
+}
+
+// openInput opens the named input for reading, '-' denotes standard input.
+func openInput(in string) (io.ReadCloser, error) {
+	if in == "-" {
+		return os.Stdin, nil
+	}
+	return os.OpenFile(in, os.O_RDONLY, 0600)
+}
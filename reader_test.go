@@ -0,0 +1,135 @@
+package csv2json
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestResolveInputs tests expanding glob patterns and validating the list of inputs.
+func TestResolveInputs(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"b.csv", "a.csv", "c.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("id\n1"), 0600); err != nil {
+			t.Fatalf("Failed to write input file: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		in      []string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "default is stdin",
+			in:   nil,
+			want: []string{"-"},
+		},
+		{
+			name: "plain files keep order",
+			in:   []string{filepath.Join(dir, "c.txt"), filepath.Join(dir, "a.csv")},
+			want: []string{filepath.Join(dir, "c.txt"), filepath.Join(dir, "a.csv")},
+		},
+		{
+			name: "glob pattern is sorted",
+			in:   []string{filepath.Join(dir, "*.csv")},
+			want: []string{filepath.Join(dir, "a.csv"), filepath.Join(dir, "b.csv")},
+		},
+		{
+			name:    "glob without match",
+			in:      []string{filepath.Join(dir, "*.json")},
+			wantErr: true,
+		},
+		{
+			name:    "missing file",
+			in:      []string{filepath.Join(dir, "missing.csv")},
+			wantErr: true,
+		},
+		{
+			name:    "stdin twice",
+			in:      []string{"-", "-"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveInputs(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveInputs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("resolveInputs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestMapMultipleInputs tests combining several inputs with their own headers into a single nested document.
+func TestMapMultipleInputs(t *testing.T) {
+	dir := t.TempDir()
+	mappingFile := filepath.Join(dir, "mapping.json")
+	mappingJSON := `{
+		"mapping": {
+			"id": {"property": "id", "type": "int"}
+		},
+		"calculated": [
+			{"property": "source.file", "kind": "application", "format": "file", "type": "string", "location": "record"},
+			{"property": "source.row", "kind": "application", "format": "file_record", "type": "int", "location": "record"},
+			{"property": "record", "kind": "application", "format": "record", "type": "int", "location": "record"},
+			{"property": "_meta.records", "kind": "application", "format": "records", "type": "int", "location": "document"}
+		]
+	}`
+	if err := os.WriteFile(mappingFile, []byte(mappingJSON), 0600); err != nil {
+		t.Fatalf("Failed to write mapping file: %v", err)
+	}
+	// the second file has a different column order, every file is read with its own header
+	first := filepath.Join(dir, "exports-1.csv")
+	if err := os.WriteFile(first, []byte("id,text\n1,a\n2,b\n"), 0600); err != nil {
+		t.Fatalf("Failed to write input file: %v", err)
+	}
+	second := filepath.Join(dir, "exports-2.csv")
+	if err := os.WriteFile(second, []byte("text,id\nc,3\n"), 0600); err != nil {
+		t.Fatalf("Failed to write input file: %v", err)
+	}
+	out := filepath.Join(dir, "out.json")
+
+	mapper, err := NewMapper(
+		WithIn(filepath.Join(dir, "exports-*.csv")),
+		WithOut(out),
+		WithNamed(true),
+		WithArray(true),
+		WithMappingFile(mappingFile),
+		WithOutputType("json"),
+		WithNestedPropertyName("items"),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create mapper: %v", err)
+	}
+	if err := mapper.Map(); err != nil {
+		t.Fatalf("Failed to map: %v", err)
+	}
+
+	outputData, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("Failed to read output file: %v", err)
+	}
+	var result map[string]any
+	if err := json.Unmarshal(outputData, &result); err != nil {
+		t.Fatalf("Failed to parse output JSON: %v", err)
+	}
+	want := map[string]any{
+		"items": []any{
+			map[string]any{"id": float64(1), "record": float64(0), "source": map[string]any{"file": first, "row": float64(0)}},
+			map[string]any{"id": float64(2), "record": float64(1), "source": map[string]any{"file": first, "row": float64(1)}},
+			map[string]any{"id": float64(3), "record": float64(2), "source": map[string]any{"file": second, "row": float64(0)}},
+		},
+		"_meta": map[string]any{"records": float64(3)},
+	}
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("Map() = %v, want %v", result, want)
+	}
+}
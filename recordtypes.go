@@ -0,0 +1,85 @@
+package csv2json
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// validateRecordTypes verifies the record types configuration.
+func (m *Mapper) validateRecordTypes() error {
+	recordTypes := m.configuration.RecordTypes
+	if len(recordTypes.Types) == 0 {
+		return nil
+	}
+	if m.named {
+		return errors.New("record types use column indices and can not be used with named input")
+	}
+	if recordTypes.Discriminator < 0 {
+		return fmt.Errorf("record_types.discriminator must be a column index (%d)", recordTypes.Discriminator)
+	}
+	switch recordTypes.Unknown {
+	case "", "error", "skip":
+	default:
+		return fmt.Errorf("unknown record_types.unknown setting %q, expected error or skip", recordTypes.Unknown)
+	}
+	for name, recordType := range recordTypes.Types {
+		switch recordType.Location {
+		case "", "record":
+		case "document":
+			if recordType.Property == "" {
+				return fmt.Errorf("record type %q is located in the document and requires a property", name)
+			}
+			if !m.writesDocument() {
+				return fmt.Errorf("record type %q is located in the document, which requires -array with -nested-property, toml, xml, template with -array or -yaml-document output", name)
+			}
+		default:
+			return fmt.Errorf("unknown location %q for record type %q", recordType.Location, name)
+		}
+	}
+	return nil
+}
+
+// recordType returns the record type of a row. If the type is unknown and unknown types are skipped, nil is returned.
+func (m *Mapper) recordType(record []string) (string, *RecordType, error) {
+	recordTypes := m.configuration.RecordTypes
+	if recordTypes.Discriminator >= len(record) {
+		return "", nil, fmt.Errorf("discriminator column %d does not exist in the record", recordTypes.Discriminator)
+	}
+	name := record[recordTypes.Discriminator]
+	recordType, ok := recordTypes.Types[name]
+	if !ok {
+		if recordTypes.Unknown == "skip" {
+			return name, nil, nil
+		}
+		return name, nil, fmt.Errorf("unknown record type %q", name)
+	}
+	if recordType.Columns > 0 && len(record) != recordType.Columns {
+		return name, nil, fmt.Errorf("record type %q requires %d columns, found %d", name, recordType.Columns, len(record))
+	}
+	return name, &recordType, nil
+}
+
+// addDocumentRecord maps a row of a record type located in the document and stores it at the property of the type.
+func (m *Mapper) addDocumentRecord(name string, recordType RecordType, record []string, recordNumber int, document map[string]any) error {
+	out, err := m.mapCSVFields(recordType.Mapping, record, nil, make(map[string]any))
+	if err != nil {
+		return err
+	}
+	out, err = m.applyCalculatedFields(recordType.Calculated, record, nil, recordNumber, out, "record")
+	if err != nil {
+		return err
+	}
+	hierarchy := strings.Split(recordType.Property, ".")
+	existing, found := getValue(hierarchy, document)
+	if !recordType.Repeated {
+		if found {
+			return fmt.Errorf("record type %q occurs more than once, set repeated to collect all rows", name)
+		}
+		setValue(hierarchy, out, document)
+		return nil
+	}
+	rows, _ := existing.([]map[string]any)
+	setValue(hierarchy, append(rows, out), document)
+	return nil
+}
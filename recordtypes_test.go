@@ -0,0 +1,204 @@
+package csv2json
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestMapWithRecordTypes tests mapping inputs with header, detail and trailer rows.
+func TestMapWithRecordTypes(t *testing.T) {
+	mappingJSON := `{
+		"record_types": {
+			"discriminator": 0,
+			"unknown": "%s",
+			"types": {
+				"H": {
+					"columns": 3,
+					"location": "document",
+					"property": "_meta.header",
+					"mapping": {
+						"1": {"property": "bank", "type": "string"},
+						"2": {"property": "date", "type": "string"}
+					}
+				},
+				"D": {
+					"columns": 4,
+					"mapping": {
+						"1": {"property": "account", "type": "string"},
+						"2": {"property": "amount", "type": "float"},
+						"3": {"property": "booked", "type": "bool"}
+					},
+					"calculated": [
+						{"property": "kind", "kind": "extra", "format": "detail", "type": "string", "location": "record"}
+					]
+				},
+				"T": {
+					"columns": 2,
+					"location": "document",
+					"property": "_meta.trailers",
+					"repeated": true,
+					"mapping": {
+						"1": {"property": "count", "type": "int"}
+					}
+				}
+			}
+		},
+		"calculated": [
+			{"property": "record", "kind": "application", "format": "record", "type": "int", "location": "record"},
+			{"property": "_meta.records", "kind": "application", "format": "records", "type": "int", "location": "document"}
+		],
+		"extra_variables": {
+			"detail": {"value": "payment"}
+		}
+	}`
+
+	tests := []struct {
+		name      string
+		unknown   string
+		input     string
+		want      map[string]any
+		errString string
+	}{
+		{
+			name:  "header detail trailer",
+			input: "H,ACME,2026-10-01\nD,DE01,12.5,true\nD,DE02,7,false\nT,2\n",
+			want: map[string]any{
+				"data": []any{
+					map[string]any{"account": "DE01", "amount": 12.5, "booked": true, "kind": "payment", "record": float64(0)},
+					map[string]any{"account": "DE02", "amount": float64(7), "booked": false, "kind": "payment", "record": float64(1)},
+				},
+				"_meta": map[string]any{
+					"header":   map[string]any{"bank": "ACME", "date": "2026-10-01"},
+					"trailers": []any{map[string]any{"count": float64(2)}},
+					"records":  float64(2),
+				},
+			},
+		},
+		{
+			name:    "unknown types are skipped",
+			unknown: "skip",
+			input:   "H,ACME,2026-10-01\nX,whatever\nD,DE01,1,true\n",
+			want: map[string]any{
+				"data": []any{
+					map[string]any{"account": "DE01", "amount": float64(1), "booked": true, "kind": "payment", "record": float64(0)},
+				},
+				"_meta": map[string]any{
+					"header":  map[string]any{"bank": "ACME", "date": "2026-10-01"},
+					"records": float64(1),
+				},
+			},
+		},
+		{
+			name:      "unknown types are an error",
+			input:     "X,whatever\n",
+			errString: `unknown record type "X"`,
+		},
+		{
+			name:      "wrong number of columns",
+			input:     "D,DE01,1\n",
+			errString: `record type "D" requires 4 columns, found 3`,
+		},
+		{
+			name:      "header occurs twice",
+			input:     "H,ACME,2026-10-01\nH,ACME,2026-10-02\n",
+			errString: `record type "H" occurs more than once`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := mapToFile(t, strings.Replace(mappingJSON, "%s", tt.unknown, 1), tt.input,
+				WithArray(true),
+				WithOutputType("json"),
+				WithNestedPropertyName("data"),
+			)
+			if tt.errString != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errString) {
+					t.Fatalf("Map() error = %v, should contain %v", err, tt.errString)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to map: %v", err)
+			}
+			var result map[string]any
+			if err := json.Unmarshal(data, &result); err != nil {
+				t.Fatalf("Failed to parse output JSON: %v", err)
+			}
+			if !reflect.DeepEqual(result, tt.want) {
+				t.Errorf("Map() = %v, want %v", result, tt.want)
+			}
+		})
+	}
+}
+
+// TestValidateRecordTypes tests the validation of the record types configuration.
+func TestValidateRecordTypes(t *testing.T) {
+	tests := []struct {
+		name        string
+		named       bool
+		marshalWith string
+		array       bool
+		nested      string
+		recordTypes RecordTypesConfiguration
+		wantErr     bool
+	}{
+		{
+			name:        "no record types",
+			named:       true,
+			recordTypes: RecordTypesConfiguration{},
+		},
+		{
+			name:        "named input",
+			named:       true,
+			recordTypes: RecordTypesConfiguration{Types: map[string]RecordType{"D": {}}},
+			wantErr:     true,
+		},
+		{
+			name:        "document without property",
+			recordTypes: RecordTypesConfiguration{Types: map[string]RecordType{"H": {Location: "document"}}},
+			wantErr:     true,
+		},
+		{
+			name:        "document in nested array",
+			marshalWith: "json",
+			array:       true,
+			nested:      "data",
+			recordTypes: RecordTypesConfiguration{Types: map[string]RecordType{"H": {Location: "document", Property: "header"}}},
+		},
+		{
+			name:        "document in xml",
+			marshalWith: "xml",
+			recordTypes: RecordTypesConfiguration{Types: map[string]RecordType{"H": {Location: "document", Property: "header"}}},
+		},
+		{
+			name:        "document in ndjson",
+			marshalWith: "json",
+			recordTypes: RecordTypesConfiguration{Types: map[string]RecordType{"H": {Location: "document", Property: "header"}}},
+			wantErr:     true,
+		},
+		{
+			name:        "document in array without nested property",
+			marshalWith: "json",
+			array:       true,
+			recordTypes: RecordTypesConfiguration{Types: map[string]RecordType{"H": {Location: "document", Property: "header"}}},
+			wantErr:     true,
+		},
+		{
+			name:        "unknown setting",
+			recordTypes: RecordTypesConfiguration{Unknown: "ignore", Types: map[string]RecordType{"D": {}}},
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Mapper{named: tt.named, marshalWith: tt.marshalWith, array: tt.array, nestedPropertyName: tt.nested, configuration: Configuration{RecordTypes: tt.recordTypes}}
+			if err := m.validateRecordTypes(); (err != nil) != tt.wantErr {
+				t.Errorf("validateRecordTypes() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
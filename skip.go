@@ -0,0 +1,215 @@
+package csv2json
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// recordFilter wraps a recordReader to drop rows before the header and trailer rows at the end of the input.
+// Trailer rows are detected by reading ahead, so only skipTrailer records are held in memory.
+type recordFilter struct {
+	reader recordReader
+
+	// headerRow is the 1-based row of the header, rows before it are dropped
+	headerRow int
+
+	// skipTrailer is the number of rows dropped at the end of the input
+	skipTrailer int
+
+	// trailerPattern drops all rows matching it
+	trailerPattern *regexp.Regexp
+
+	// separator is used to join the fields of a row before matching trailerPattern
+	separator string
+
+	// fields is the number of fields expected per row, 0 until the first row is read, -1 disables the check
+	fields int
+
+	// row counts the rows returned, used in error messages
+	row int
+
+	// pending holds rows read ahead to detect the trailer
+	pending [][]string
+}
+
+// skipPreamble discards leading lines of the input until skipLines lines are dropped and, if set,
+// a line matches skipUntil. The line matching skipUntil is kept. The dropped lines are returned.
+func (m *Mapper) skipPreamble(in io.Reader) (io.Reader, []string, error) {
+	if m.skipLines <= 0 && m.skipUntil == nil {
+		return in, nil, nil
+	}
+	reader := bufio.NewReader(in)
+	var preamble []string
+	for {
+		if len(preamble) >= m.skipLines {
+			if m.skipUntil == nil {
+				break
+			}
+			next, err := peekLine(reader)
+			if err != nil && err != io.EOF {
+				return nil, nil, err
+			}
+			if m.skipUntil.MatchString(next) {
+				break
+			}
+		}
+		line, err := reader.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			if err == io.EOF {
+				return nil, nil, fmt.Errorf("end of input reached while skipping preamble (%d lines)", len(preamble))
+			}
+			return nil, nil, err
+		}
+		preamble = append(preamble, strings.TrimRight(line, "\r\n"))
+	}
+	return reader, preamble, nil
+}
+
+// pendingReader returns a row read ahead before the rows of the underlying reader.
+type pendingReader struct {
+	reader  recordReader
+	pending []string
+}
+
+// readHeader returns the row read ahead or the header of the underlying reader.
+func (p *pendingReader) readHeader() ([]string, error) {
+	if p.pending != nil {
+		return p.take(), nil
+	}
+	return p.reader.readHeader()
+}
+
+// read returns the row read ahead or the next row of the underlying reader.
+func (p *pendingReader) read() ([]string, error) {
+	if p.pending != nil {
+		return p.take(), nil
+	}
+	return p.reader.read()
+}
+
+// take returns the row read ahead and clears it.
+func (p *pendingReader) take() []string {
+	row := p.pending
+	p.pending = nil
+	return row
+}
+
+// skipPreambleRows is the equivalent of skipPreamble for inputs not read line by line. Every row is treated
+// as a line with the fields joined by the separator. The dropped rows are returned as lines.
+func (m *Mapper) skipPreambleRows(reader recordReader) (recordReader, []string, error) {
+	if m.skipLines <= 0 && m.skipUntil == nil {
+		return reader, nil, nil
+	}
+	var preamble []string
+	for {
+		row, err := reader.read()
+		if err == io.EOF {
+			return nil, nil, fmt.Errorf("end of input reached while skipping preamble (%d rows)", len(preamble))
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		line := strings.Join(row, string(m.separator))
+		if len(preamble) >= m.skipLines && (m.skipUntil == nil || m.skipUntil.MatchString(line)) {
+			return &pendingReader{reader: reader, pending: slices.Clone(row)}, preamble, nil
+		}
+		preamble = append(preamble, line)
+	}
+}
+
+// peekLine returns the next line of reader without consuming it.
+func peekLine(reader *bufio.Reader) (string, error) {
+	for n := 64; ; n *= 2 {
+		data, err := reader.Peek(n)
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			return strings.TrimRight(string(data[:i]), "\r"), nil
+		}
+		if err == bufio.ErrBufferFull {
+			// the line is longer than the buffer, match what is available
+			return string(data), nil
+		}
+		if err != nil {
+			return string(data), err
+		}
+	}
+}
+
+// readHeader drops the rows before the header row and returns the header.
+func (f *recordFilter) readHeader() ([]string, error) {
+	for i := 1; i < f.headerRow; i++ {
+		if _, err := f.reader.read(); err != nil {
+			if err == io.EOF {
+				return nil, fmt.Errorf("end of input reached before header row %d", f.headerRow)
+			}
+			return nil, err
+		}
+	}
+	header, err := f.reader.readHeader()
+	if err != nil {
+		return nil, err
+	}
+	f.fields = len(header)
+	return header, nil
+}
+
+// read returns the next row that is not part of the trailer.
+func (f *recordFilter) read() ([]string, error) {
+	for {
+		record, err := f.reader.read()
+		if err == io.EOF {
+			// everything still pending is the trailer
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, err
+		}
+		if f.trailerPattern != nil && f.trailerPattern.MatchString(strings.Join(record, f.separator)) {
+			continue
+		}
+		if f.skipTrailer > 0 {
+			// the underlying reader may reuse the record, so keep a copy
+			f.pending = append(f.pending, slices.Clone(record))
+			if len(f.pending) <= f.skipTrailer {
+				continue
+			}
+			record = f.pending[0]
+			f.pending = f.pending[1:]
+		}
+		f.row++
+		if f.fields == 0 {
+			f.fields = len(record)
+		} else if f.fields > 0 && len(record) != f.fields {
+			return nil, fmt.Errorf("row %d: %w", f.row, csv.ErrFieldCount)
+		}
+		return record, nil
+	}
+}
+
+// filterRecords wraps reader in a recordFilter if rows before the header or trailer rows have to be dropped.
+func (m *Mapper) filterRecords(reader recordReader) recordReader {
+	if m.headerRow <= 1 && m.skipTrailer <= 0 && m.trailerPattern == nil {
+		return reader
+	}
+	if c, ok := reader.(*csvReader); ok {
+		// field counts are checked by the filter, dropped rows may have a different number of fields
+		c.reader.FieldsPerRecord = -1
+	}
+	filter := &recordFilter{
+		reader:         reader,
+		headerRow:      m.headerRow,
+		skipTrailer:    m.skipTrailer,
+		trailerPattern: m.trailerPattern,
+		separator:      string(m.separator),
+	}
+	if len(m.configuration.RecordTypes.Types) > 0 {
+		// every record type has its own number of columns
+		filter.fields = -1
+	}
+	return filter
+}
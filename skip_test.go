@@ -0,0 +1,186 @@
+package csv2json
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestSkipPreamble tests skipping leading lines by count and by regular expression.
+func TestSkipPreamble(t *testing.T) {
+	input := "Report: Sales\nGenerated: 2026-10-01\n\nid,text\n1,hello\n"
+
+	tests := []struct {
+		name         string
+		skipLines    int
+		skipUntil    string
+		wantPreamble []string
+		wantRest     string
+		wantErr      bool
+	}{
+		{
+			name:     "nothing to skip",
+			wantRest: input,
+		},
+		{
+			name:         "skip lines",
+			skipLines:    3,
+			wantPreamble: []string{"Report: Sales", "Generated: 2026-10-01", ""},
+			wantRest:     "id,text\n1,hello\n",
+		},
+		{
+			name:         "skip until header",
+			skipUntil:    "^id,",
+			wantPreamble: []string{"Report: Sales", "Generated: 2026-10-01", ""},
+			wantRest:     "id,text\n1,hello\n",
+		},
+		{
+			name:         "skip lines then until",
+			skipLines:    1,
+			skipUntil:    "^$",
+			wantPreamble: []string{"Report: Sales", "Generated: 2026-10-01"},
+			wantRest:     "\nid,text\n1,hello\n",
+		},
+		{
+			name:      "pattern never matches",
+			skipUntil: "^never$",
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Mapper{skipLines: tt.skipLines}
+			if tt.skipUntil != "" {
+				m.skipUntil = regexp.MustCompile(tt.skipUntil)
+			}
+			rest, preamble, err := m.skipPreamble(strings.NewReader(input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("skipPreamble() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			data, _ := io.ReadAll(rest)
+			if string(data) != tt.wantRest {
+				t.Errorf("skipPreamble() rest = %q, want %q", data, tt.wantRest)
+			}
+			if !reflect.DeepEqual(preamble, tt.wantPreamble) {
+				t.Errorf("skipPreamble() preamble = %q, want %q", preamble, tt.wantPreamble)
+			}
+		})
+	}
+}
+
+// TestRecordFilter tests dropping rows before the header and trailer rows.
+func TestRecordFilter(t *testing.T) {
+	input := "title\n\"sub,title\",x\nid,value\n1,a\n2,b\n3,c\nTotal,3,extra\n"
+
+	tests := []struct {
+		name           string
+		headerRow      int
+		skipTrailer    int
+		trailerPattern string
+		wantHeader     []string
+		want           [][]string
+		wantErr        error
+	}{
+		{
+			name:        "header row and trailer count",
+			headerRow:   3,
+			skipTrailer: 1,
+			wantHeader:  []string{"id", "value"},
+			want:        [][]string{{"1", "a"}, {"2", "b"}, {"3", "c"}},
+		},
+		{
+			name:           "trailer pattern",
+			headerRow:      3,
+			trailerPattern: "^Total,",
+			wantHeader:     []string{"id", "value"},
+			want:           [][]string{{"1", "a"}, {"2", "b"}, {"3", "c"}},
+		},
+		{
+			name:        "more trailer rows",
+			headerRow:   3,
+			skipTrailer: 2,
+			wantHeader:  []string{"id", "value"},
+			want:        [][]string{{"1", "a"}, {"2", "b"}},
+		},
+		{
+			name:       "trailer with different field count is kept",
+			headerRow:  3,
+			wantHeader: []string{"id", "value"},
+			wantErr:    csv.ErrFieldCount,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Mapper{separator: ',', named: true, headerRow: tt.headerRow, skipTrailer: tt.skipTrailer}
+			if tt.trailerPattern != "" {
+				m.trailerPattern = regexp.MustCompile(tt.trailerPattern)
+			}
+			reader, err := m.newRecordReader(strings.NewReader(input))
+			if err != nil {
+				t.Fatalf("newRecordReader() error = %v", err)
+			}
+			header, err := reader.readHeader()
+			if err != nil {
+				t.Fatalf("readHeader() error = %v", err)
+			}
+			if !reflect.DeepEqual(header, tt.wantHeader) {
+				t.Errorf("readHeader() = %q, want %q", header, tt.wantHeader)
+			}
+			var got [][]string
+			for {
+				record, err := reader.read()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					if tt.wantErr == nil || !errors.Is(err, tt.wantErr) {
+						t.Fatalf("read() error = %v, want %v", err, tt.wantErr)
+					}
+					return
+				}
+				// records may be reused by the reader
+				got = append(got, slices.Clone(record))
+			}
+			if tt.wantErr != nil {
+				t.Fatalf("read() error = nil, want %v", tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("read() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestMapSkipPreambleAndTrailer tests the Map method on a report with title lines and a totals row.
+func TestMapSkipPreambleAndTrailer(t *testing.T) {
+	data, err := mapToFile(t, `{"mapping": {"id": {"property": "id", "type": "int"}}}`, "Sales \"Q3\" report\n\nid,text\n1,a\n2,b\nsum,2\n",
+		WithNamed(true),
+		WithArray(true),
+		WithOutputType("json"),
+		WithSkipUntil("^id,"),
+		WithSkipTrailer(1),
+	)
+	if err != nil {
+		t.Fatalf("Failed to map: %v", err)
+	}
+	if string(data) != `[{"id":1},{"id":2}]` {
+		t.Errorf("Map() = %s, want %s", data, `[{"id":1},{"id":2}]`)
+	}
+}
+
+// TestHeaderRowRequiresNamed tests that a header row can only be set for named input.
+func TestHeaderRowRequiresNamed(t *testing.T) {
+	if _, err := NewMapper(WithIn("input.csv"), WithOut("-"), WithHeaderRow(2)); err == nil {
+		t.Errorf("NewMapper() error = nil, want error for -header-row without -named")
+	}
+}
@@ -0,0 +1,273 @@
+package csv2json
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// sqlWriter writes records as batched INSERT statements. Nested properties are flattened to columns named
+// after their property path joined by an underscore.
+type sqlWriter struct {
+	mapper *Mapper
+	out    io.Writer
+
+	// columns holds the dotted property paths written as columns
+	columns []string
+
+	// names holds the quoted column names
+	names []string
+
+	// batch holds the value lists of the pending statement
+	batch []string
+
+	// keyColumns holds the indices of the upsert key columns
+	keyColumns []int
+
+	// batchKeys holds the upsert keys of the pending statement, a statement may not affect a row twice
+	batchKeys map[string]bool
+}
+
+// newSQLWriter creates an sqlWriter and writes the CREATE TABLE statement if requested.
+func (m *Mapper) newSQLWriter(out io.Writer, recordOrder []string) (*sqlWriter, error) {
+	w := &sqlWriter{mapper: m, out: out, columns: csvColumns(recordOrder)}
+	for _, column := range w.columns {
+		w.names = append(w.names, m.quoteIdentifier(sqlColumn(column)))
+	}
+	for _, key := range m.upsertKeys {
+		// keys may be given as property path or column name
+		index := slices.IndexFunc(w.columns, func(column string) bool { return column == key || sqlColumn(column) == key })
+		if index < 0 {
+			return nil, fmt.Errorf("upsert key %q is not a column", key)
+		}
+		w.keyColumns = append(w.keyColumns, index)
+	}
+	w.batchKeys = make(map[string]bool)
+	if m.createTable {
+		if _, err := io.WriteString(out, m.createTableStatement(w.columns)); err != nil {
+			return nil, err
+		}
+	}
+	return w, nil
+}
+
+// sqlColumn returns the column name of a property path.
+func sqlColumn(property string) string {
+	return strings.ReplaceAll(property, ".", "_")
+}
+
+// quoteIdentifier quotes a table or column name for the dialect.
+func (m *Mapper) quoteIdentifier(name string) string {
+	if m.dialect == "mysql" {
+		return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// createTableStatement returns a CREATE TABLE statement with column types derived from the mapping. The
+// upsert keys form the primary key, MySQL text keys are declared as VARCHAR(255).
+func (m *Mapper) createTableStatement(columns []string) string {
+	types := m.propertyTypes()
+	var b strings.Builder
+	b.WriteString("CREATE TABLE IF NOT EXISTS " + m.quoteIdentifier(m.table) + " (\n")
+	for i, column := range columns {
+		if i > 0 {
+			b.WriteString(",\n")
+		}
+		sqlType := m.sqlType(types[column])
+		if sqlType == "TEXT" && m.dialect == "mysql" && slices.ContainsFunc(m.upsertKeys, func(key string) bool { return key == column || key == sqlColumn(column) }) {
+			// MySQL can not use TEXT columns in a key without a prefix length
+			sqlType = "VARCHAR(255)"
+		}
+		b.WriteString("  " + m.quoteIdentifier(sqlColumn(column)) + " " + sqlType)
+	}
+	if len(m.upsertKeys) > 0 {
+		keys := make([]string, len(m.upsertKeys))
+		for i, key := range m.upsertKeys {
+			keys[i] = m.quoteIdentifier(sqlColumn(key))
+		}
+		b.WriteString(",\n  PRIMARY KEY (" + strings.Join(keys, ", ") + ")")
+	}
+	b.WriteString("\n);\n")
+	return b.String()
+}
+
+// propertyTypes returns the configured type of every property of the mapping, the record types and the
+// record-level calculated fields.
+func (m *Mapper) propertyTypes() map[string]string {
+	types := make(map[string]string)
+	addMapping := func(mapping map[string]ColumnConfiguration) {
+		for _, column := range mapping {
+			types[column.Property] = column.Type
+		}
+	}
+	addCalculated := func(calculated []CalculatedField) {
+		for _, field := range calculated {
+			if field.Location == "record" {
+				types[field.Property] = field.Type
+			}
+		}
+	}
+	addMapping(m.configuration.Mapping)
+	addCalculated(m.configuration.Calculated)
+	for _, recordType := range m.configuration.RecordTypes.Types {
+		if recordType.Location != "document" {
+			addMapping(recordType.Mapping)
+			addCalculated(recordType.Calculated)
+		}
+	}
+	return types
+}
+
+// sqlType returns the column type of the dialect for a mapping type.
+func (m *Mapper) sqlType(t string) string {
+	switch t {
+	case "int":
+		if m.dialect == "sqlite" {
+			return "INTEGER"
+		}
+		return "BIGINT"
+	case "float":
+		switch m.dialect {
+		case "mysql":
+			return "DOUBLE"
+		case "sqlite":
+			return "REAL"
+		}
+		return "DOUBLE PRECISION"
+	case "bool":
+		if m.dialect == "sqlite" {
+			return "INTEGER"
+		}
+		return "BOOLEAN"
+	}
+	return "TEXT"
+}
+
+// write adds the record to the pending statement and writes the statement once the batch is full.
+func (w *sqlWriter) write(record map[string]any) error {
+	values := make(map[string]any)
+	flattenRecord(record, "", values)
+	literals := make([]string, len(w.columns))
+	for i, column := range w.columns {
+		literal, err := w.mapper.sqlLiteral(values[column])
+		if err != nil {
+			return fmt.Errorf("column %q: %w", column, err)
+		}
+		literals[i] = literal
+	}
+	if len(w.keyColumns) > 0 {
+		keys := make([]string, len(w.keyColumns))
+		for i, index := range w.keyColumns {
+			keys[i] = literals[index]
+		}
+		key := strings.Join(keys, "\x00")
+		if w.batchKeys[key] {
+			// the record updates a row of the pending statement, so it starts a new statement
+			if err := w.flush(); err != nil {
+				return err
+			}
+		}
+		w.batchKeys[key] = true
+	}
+	w.batch = append(w.batch, "("+strings.Join(literals, ", ")+")")
+	if len(w.batch) >= w.mapper.batchSize {
+		return w.flush()
+	}
+	return nil
+}
+
+// close writes the pending statement.
+func (w *sqlWriter) close(map[string]any) error {
+	return w.flush()
+}
+
+// flush writes an INSERT statement for the records of the batch.
+func (w *sqlWriter) flush() error {
+	if len(w.batch) == 0 {
+		return nil
+	}
+	var b bytes.Buffer
+	b.WriteString("INSERT INTO " + w.mapper.quoteIdentifier(w.mapper.table) + " (" + strings.Join(w.names, ", ") + ") VALUES\n  ")
+	b.WriteString(strings.Join(w.batch, ",\n  "))
+	if len(w.mapper.upsertKeys) > 0 {
+		b.WriteString(w.upsertClause())
+	}
+	b.WriteString(";\n")
+	w.batch = w.batch[:0]
+	clear(w.batchKeys)
+	_, err := w.out.Write(b.Bytes())
+	return err
+}
+
+// upsertClause returns the clause updating existing rows with the same upsert keys.
+func (w *sqlWriter) upsertClause() string {
+	var keys, updates []string
+	for _, key := range w.mapper.upsertKeys {
+		keys = append(keys, w.mapper.quoteIdentifier(sqlColumn(key)))
+	}
+	for _, name := range w.names {
+		if slices.Contains(keys, name) {
+			continue
+		}
+		if w.mapper.dialect == "mysql" {
+			updates = append(updates, name+" = VALUES("+name+")")
+		} else {
+			updates = append(updates, name+" = excluded."+name)
+		}
+	}
+	if w.mapper.dialect == "mysql" {
+		if len(updates) == 0 {
+			// keep the existing row
+			return "\nON DUPLICATE KEY UPDATE " + keys[0] + " = " + keys[0]
+		}
+		return "\nON DUPLICATE KEY UPDATE " + strings.Join(updates, ", ")
+	}
+	if len(updates) == 0 {
+		return "\nON CONFLICT (" + strings.Join(keys, ", ") + ") DO NOTHING"
+	}
+	return "\nON CONFLICT (" + strings.Join(keys, ", ") + ") DO UPDATE SET " + strings.Join(updates, ", ")
+}
+
+// sqlLiteral returns the SQL literal of a value. Objects and arrays are written as JSON text.
+func (m *Mapper) sqlLiteral(value any) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "NULL", nil
+	case string:
+		return m.quoteString(v), nil
+	case bool:
+		if m.dialect == "sqlite" {
+			if v {
+				return "1", nil
+			}
+			return "0", nil
+		}
+		return strings.ToUpper(strconv.FormatBool(v)), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case float64:
+		var buf bytes.Buffer
+		if err := m.encoder.encodeFloat(&buf, v, 64); err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+	}
+	d, err := m.encoder.marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return m.quoteString(string(d)), nil
+}
+
+// quoteString returns a string literal. MySQL treats backslashes as escape characters, so they are doubled.
+func (m *Mapper) quoteString(s string) string {
+	s = strings.ReplaceAll(s, "'", "''")
+	if m.dialect == "mysql" {
+		s = strings.ReplaceAll(s, `\`, `\\`)
+	}
+	return "'" + s + "'"
+}
@@ -0,0 +1,129 @@
+package csv2json
+
+import (
+	"testing"
+)
+
+// TestMapSQLOutput tests writing records as INSERT statements for the supported dialects.
+func TestMapSQLOutput(t *testing.T) {
+	mappingJSON := `{
+		"mapping": {
+			"0": {"property": "id", "type": "int"},
+			"1": {"property": "name.first", "type": "string"},
+			"2": {"property": "price", "type": "float"},
+			"3": {"property": "active", "type": "bool"}
+		}
+	}`
+	input := "1,O'Brien,1.5,true\n2,C:\\temp,2,false\n3,Joe,0.25,true\n"
+
+	tests := []struct {
+		name    string
+		options []OptionFunc
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "postgres",
+			want: `INSERT INTO "data" ("id", "name_first", "price", "active") VALUES
+  (1, 'O''Brien', 1.5, TRUE),
+  (2, 'C:\temp', 2, FALSE),
+  (3, 'Joe', 0.25, TRUE);
+`,
+		},
+		{
+			name:    "batches",
+			options: []OptionFunc{WithBatchSize(2), WithTable("items")},
+			want: `INSERT INTO "items" ("id", "name_first", "price", "active") VALUES
+  (1, 'O''Brien', 1.5, TRUE),
+  (2, 'C:\temp', 2, FALSE);
+INSERT INTO "items" ("id", "name_first", "price", "active") VALUES
+  (3, 'Joe', 0.25, TRUE);
+`,
+		},
+		{
+			name:    "postgres upsert with create table",
+			options: []OptionFunc{WithBatchSize(3), WithUpsertKeys("id"), WithCreateTable(true)},
+			want: `CREATE TABLE IF NOT EXISTS "data" (
+  "id" BIGINT,
+  "name_first" TEXT,
+  "price" DOUBLE PRECISION,
+  "active" BOOLEAN,
+  PRIMARY KEY ("id")
+);
+INSERT INTO "data" ("id", "name_first", "price", "active") VALUES
+  (1, 'O''Brien', 1.5, TRUE),
+  (2, 'C:\temp', 2, FALSE),
+  (3, 'Joe', 0.25, TRUE)
+ON CONFLICT ("id") DO UPDATE SET "name_first" = excluded."name_first", "price" = excluded."price", "active" = excluded."active";
+`,
+		},
+		{
+			name:    "mysql upsert with text key and create table",
+			options: []OptionFunc{WithDialect("mysql"), WithBatchSize(3), WithUpsertKeys("id", "name_first"), WithCreateTable(true)},
+			want: "CREATE TABLE IF NOT EXISTS `data` (\n" +
+				"  `id` BIGINT,\n" +
+				"  `name_first` VARCHAR(255),\n" +
+				"  `price` DOUBLE,\n" +
+				"  `active` BOOLEAN,\n" +
+				"  PRIMARY KEY (`id`, `name_first`)\n" +
+				");\n" +
+				"INSERT INTO `data` (`id`, `name_first`, `price`, `active`) VALUES\n" +
+				"  (1, 'O''Brien', 1.5, TRUE),\n" +
+				"  (2, 'C:\\\\temp', 2, FALSE),\n" +
+				"  (3, 'Joe', 0.25, TRUE)\n" +
+				"ON DUPLICATE KEY UPDATE `price` = VALUES(`price`), `active` = VALUES(`active`);\n",
+		},
+		{
+			name:    "upsert key repeated within batch",
+			options: []OptionFunc{WithBatchSize(3), WithUpsertKeys("active")},
+			want: `INSERT INTO "data" ("id", "name_first", "price", "active") VALUES
+  (1, 'O''Brien', 1.5, TRUE),
+  (2, 'C:\temp', 2, FALSE)
+ON CONFLICT ("active") DO UPDATE SET "id" = excluded."id", "name_first" = excluded."name_first", "price" = excluded."price";
+INSERT INTO "data" ("id", "name_first", "price", "active") VALUES
+  (3, 'Joe', 0.25, TRUE)
+ON CONFLICT ("active") DO UPDATE SET "id" = excluded."id", "name_first" = excluded."name_first", "price" = excluded."price";
+`,
+		},
+		{
+			name:    "sqlite",
+			options: []OptionFunc{WithDialect("sqlite"), WithBatchSize(3), WithCreateTable(true)},
+			want: `CREATE TABLE IF NOT EXISTS "data" (
+  "id" INTEGER,
+  "name_first" TEXT,
+  "price" REAL,
+  "active" INTEGER
+);
+INSERT INTO "data" ("id", "name_first", "price", "active") VALUES
+  (1, 'O''Brien', 1.5, 1),
+  (2, 'C:\temp', 2, 0),
+  (3, 'Joe', 0.25, 1);
+`,
+		},
+		{
+			name:    "unknown upsert key",
+			options: []OptionFunc{WithUpsertKeys("code")},
+			wantErr: true,
+		},
+		{
+			name:    "unknown dialect",
+			options: []OptionFunc{WithDialect("oracle")},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := mapToFile(t, mappingJSON, input, append([]OptionFunc{WithOutputType("sql")}, tt.options...)...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Map() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if string(data) != tt.want {
+				t.Errorf("Map() = %s, want %s", data, tt.want)
+			}
+		})
+	}
+}
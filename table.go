@@ -0,0 +1,171 @@
+package csv2json
+
+import (
+	"bytes"
+	"html"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// tableCell is a rendered cell of table output
+type tableCell struct {
+	text string
+
+	// number cells are right-aligned in aligned text tables
+	number bool
+}
+
+// tableWriter renders records as Markdown, HTML or aligned text table. Nested properties are flattened to
+// columns, the header lists the declared properties of the mapping. Markdown and HTML rows are written as they
+// are mapped, aligned text tables are written once all column widths are known.
+type tableWriter struct {
+	mapper *Mapper
+	out    io.Writer
+
+	// columns holds the dotted property paths written as columns
+	columns []string
+
+	// rows collects the rows of aligned text tables, starting with the header
+	rows [][]tableCell
+
+	buf bytes.Buffer
+}
+
+// newTableWriter creates a tableWriter and writes the header of Markdown and HTML tables.
+func (m *Mapper) newTableWriter(out io.Writer, recordOrder []string) (*tableWriter, error) {
+	w := &tableWriter{mapper: m, out: out, columns: csvColumns(recordOrder)}
+	header := make([]tableCell, len(w.columns))
+	for i, column := range w.columns {
+		header[i] = tableCell{text: w.truncate(strings.ReplaceAll(column, ".", m.flattenSeparator))}
+	}
+	switch m.marshalWith {
+	case "markdown":
+		w.markdownRow(header)
+		w.buf.WriteByte('|')
+		for range w.columns {
+			w.buf.WriteString(" --- |")
+		}
+		w.buf.WriteByte('\n')
+	case "html":
+		w.buf.WriteString("<table>\n<thead>\n")
+		w.htmlRow(header, "th")
+		w.buf.WriteString("</thead>\n<tbody>\n")
+	default:
+		w.rows = append(w.rows, header)
+		return w, nil
+	}
+	if _, err := out.Write(w.buf.Bytes()); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// write renders the record as a row.
+func (w *tableWriter) write(record map[string]any) error {
+	values := make(map[string]any)
+	flattenRecord(record, "", values)
+	row := make([]tableCell, len(w.columns))
+	for i, column := range w.columns {
+		text, _, err := w.mapper.cellText(values[column])
+		if err != nil {
+			return err
+		}
+		row[i] = tableCell{text: w.truncate(text)}
+		switch values[column].(type) {
+		case int, float64:
+			row[i].number = true
+		}
+	}
+	w.buf.Reset()
+	switch w.mapper.marshalWith {
+	case "markdown":
+		w.markdownRow(row)
+	case "html":
+		w.htmlRow(row, "td")
+	default:
+		w.rows = append(w.rows, row)
+		return nil
+	}
+	_, err := w.out.Write(w.buf.Bytes())
+	return err
+}
+
+// close ends HTML tables and writes aligned text tables.
+func (w *tableWriter) close(map[string]any) error {
+	w.buf.Reset()
+	switch w.mapper.marshalWith {
+	case "markdown":
+		return nil
+	case "html":
+		w.buf.WriteString("</tbody>\n</table>\n")
+	default:
+		w.alignedTable()
+	}
+	_, err := w.out.Write(w.buf.Bytes())
+	return err
+}
+
+// truncate shortens text to the maximum column width, marking the truncation with an ellipsis.
+func (w *tableWriter) truncate(text string) string {
+	if w.mapper.maxWidth == 0 || utf8.RuneCountInString(text) <= w.mapper.maxWidth {
+		return text
+	}
+	return string([]rune(text)[:w.mapper.maxWidth-1]) + "…"
+}
+
+// markdownEscaper escapes characters breaking Markdown table rows
+var markdownEscaper = strings.NewReplacer(`\`, `\\`, "|", `\|`, "\r\n", "<br>", "\n", "<br>", "\r", "<br>")
+
+// markdownRow renders a row of a Markdown table.
+func (w *tableWriter) markdownRow(row []tableCell) {
+	w.buf.WriteByte('|')
+	for _, cell := range row {
+		w.buf.WriteString(" " + markdownEscaper.Replace(cell.text) + " |")
+	}
+	w.buf.WriteByte('\n')
+}
+
+// htmlRow renders a row of an HTML table using th or td cells.
+func (w *tableWriter) htmlRow(row []tableCell, element string) {
+	w.buf.WriteString("<tr>")
+	for _, cell := range row {
+		w.buf.WriteString("<" + element + ">" + html.EscapeString(cell.text) + "</" + element + ">")
+	}
+	w.buf.WriteString("</tr>\n")
+}
+
+// textEscaper replaces characters breaking the lines of aligned text tables
+var textEscaper = strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ", "\t", " ")
+
+// alignedTable renders the collected rows as text table with columns separated by two spaces. The header is
+// underlined, numbers are right-aligned.
+func (w *tableWriter) alignedTable() {
+	widths := make([]int, len(w.columns))
+	for _, row := range w.rows {
+		for i := range row {
+			row[i].text = textEscaper.Replace(row[i].text)
+			widths[i] = max(widths[i], utf8.RuneCountInString(row[i].text))
+		}
+	}
+	underline := make([]tableCell, len(w.columns))
+	for i, width := range widths {
+		underline[i] = tableCell{text: strings.Repeat("-", width)}
+	}
+	rows := append([][]tableCell{w.rows[0], underline}, w.rows[1:]...)
+	for _, row := range rows {
+		var line strings.Builder
+		for i, cell := range row {
+			if i > 0 {
+				line.WriteString("  ")
+			}
+			padding := strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell.text))
+			if cell.number {
+				line.WriteString(padding + cell.text)
+			} else {
+				line.WriteString(cell.text + padding)
+			}
+		}
+		w.buf.WriteString(strings.TrimRight(line.String(), " ") + "\n")
+	}
+}
@@ -0,0 +1,85 @@
+package csv2json
+
+import (
+	"testing"
+)
+
+// TestMapTableOutput tests rendering records as Markdown, HTML and aligned text tables.
+func TestMapTableOutput(t *testing.T) {
+	mappingJSON := `{
+		"mapping": {
+			"0": {"property": "id", "type": "int"},
+			"1": {"property": "name.first", "type": "string"},
+			"2": {"property": "price", "type": "float"}
+		}
+	}`
+	input := "1,Jane,1.5\n12,\"a|b <c>\nd\",10\n"
+
+	tests := []struct {
+		name       string
+		outputType string
+		options    []OptionFunc
+		want       string
+		wantErr    bool
+	}{
+		{
+			name:       "markdown",
+			outputType: "markdown",
+			want: "| id | name.first | price |\n| --- | --- | --- |\n" +
+				"| 1 | Jane | 1.5 |\n" +
+				"| 12 | a\\|b <c><br>d | 10 |\n",
+		},
+		{
+			name:       "html",
+			outputType: "html",
+			want: "<table>\n<thead>\n<tr><th>id</th><th>name.first</th><th>price</th></tr>\n</thead>\n<tbody>\n" +
+				"<tr><td>1</td><td>Jane</td><td>1.5</td></tr>\n" +
+				"<tr><td>12</td><td>a|b &lt;c&gt;\nd</td><td>10</td></tr>\n" +
+				"</tbody>\n</table>\n",
+		},
+		{
+			name:       "table",
+			outputType: "table",
+			want: "id  name.first  price\n" +
+				"--  ----------  -----\n" +
+				" 1  Jane          1.5\n" +
+				"12  a|b <c> d      10\n",
+		},
+		{
+			name:       "table with max width",
+			outputType: "table",
+			options:    []OptionFunc{WithMaxWidth(5), WithFlattenSeparator("_")},
+			want: "id  name…  price\n" +
+				"--  -----  -----\n" +
+				" 1  Jane     1.5\n" +
+				"12  a|b …     10\n",
+		},
+		{
+			name:       "array",
+			outputType: "markdown",
+			options:    []OptionFunc{WithArray(true)},
+			wantErr:    true,
+		},
+		{
+			name:       "negative max width",
+			outputType: "table",
+			options:    []OptionFunc{WithMaxWidth(-1)},
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := mapToFile(t, mappingJSON, input, append([]OptionFunc{WithOutputType(tt.outputType)}, tt.options...)...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Map() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if string(data) != tt.want {
+				t.Errorf("Map() = %q, want %q", data, tt.want)
+			}
+		})
+	}
+}
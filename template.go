@@ -0,0 +1,129 @@
+package csv2json
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// templateWriter writes records using a text/template. Without -array the template is executed for every record,
+// with -array it is executed once for the document holding all records and the document-level properties.
+type templateWriter struct {
+	mapper   *Mapper
+	out      io.Writer
+	template *template.Template
+
+	// records collects the records of array output
+	records []any
+}
+
+// newTemplateWriter parses the template file.
+func (m *Mapper) newTemplateWriter(out io.Writer) (*templateWriter, error) {
+	t, err := template.New(filepath.Base(m.templateFile)).Funcs(m.templateFuncs()).ParseFiles(m.templateFile)
+	if err != nil {
+		return nil, err
+	}
+	return &templateWriter{mapper: m, out: out, template: t}, nil
+}
+
+// write executes the template for the record or collects it for array output.
+func (w *templateWriter) write(record map[string]any) error {
+	if w.mapper.array {
+		w.records = append(w.records, record)
+		return nil
+	}
+	return w.template.Execute(w.out, record)
+}
+
+// close executes the template for the document of array output. The records are available under the document
+// property.
+func (w *templateWriter) close(document map[string]any) error {
+	if !w.mapper.array {
+		return nil
+	}
+	data := maps.Clone(document)
+	records := w.records
+	if records == nil {
+		records = []any{}
+	}
+	data[w.mapper.documentProperty()] = records
+	return w.template.Execute(w.out, data)
+}
+
+// templateFuncs returns the functions available to templates in addition to the predefined functions.
+func (m *Mapper) templateFuncs() template.FuncMap {
+	return template.FuncMap{
+		// json encodes a value as JSON using the JSON encoding options
+		"json": func(value any) (string, error) {
+			d, err := m.encoder.marshal(value)
+			return string(d), err
+		},
+		// yaml encodes a value as YAML
+		"yaml": func(value any) (string, error) {
+			d, err := yaml.Marshal(value)
+			return strings.TrimSuffix(string(d), "\n"), err
+		},
+		// quote returns a double-quoted string with Go escape sequences
+		"quote": func(value any) string {
+			return strconv.Quote(templateText(value))
+		},
+		// join joins the elements of a list with a separator
+		"join": func(separator string, list any) (string, error) {
+			var texts []string
+			switch l := list.(type) {
+			case nil:
+			case []string:
+				texts = l
+			case []any:
+				for _, value := range l {
+					texts = append(texts, templateText(value))
+				}
+			default:
+				return "", fmt.Errorf("join expects a list, found %T", list)
+			}
+			return strings.Join(texts, separator), nil
+		},
+		// default returns the default value if the value is missing or empty
+		"default": func(defaultValue, value any) any {
+			if value == nil || value == "" {
+				return defaultValue
+			}
+			return value
+		},
+		// now returns the current time
+		"now": time.Now,
+		// date formats a time, a RFC 3339 string or Unix seconds using a Go time layout
+		"date": func(layout string, value any) (string, error) {
+			var t time.Time
+			switch v := value.(type) {
+			case time.Time:
+				t = v
+			case string:
+				var err error
+				if t, err = time.Parse(time.RFC3339, v); err != nil {
+					return "", err
+				}
+			case int:
+				t = time.Unix(int64(v), 0).UTC()
+			default:
+				return "", fmt.Errorf("date expects a time, found %T", value)
+			}
+			return t.Format(layout), nil
+		},
+	}
+}
+
+// templateText returns the text of a value as printed by templates.
+func templateText(value any) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,118 @@
+package csv2json
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestMapTemplateOutput tests writing records using a template per record and for the whole document.
+func TestMapTemplateOutput(t *testing.T) {
+	mappingJSON := `{
+		"mapping": {
+			"0": {"property": "id", "type": "int"},
+			"1": {"property": "name.first", "type": "string"},
+			"2": {"property": "created", "type": "string"},
+			"3": {"property": "nick", "type": "string"}
+		},
+		"calculated": [
+			{"property": "total", "kind": "application", "format": "records", "type": "int", "location": "document"}
+		]
+	}`
+	input := "1,Jane,2024-03-01T10:00:00Z,\n2,\"Joe \"\"J\"\"\",2024-12-24T18:30:00Z,jj\n"
+
+	tests := []struct {
+		name     string
+		template string
+		options  []OptionFunc
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "per record",
+			template: `INSERT INTO t VALUES ({{.id}}, {{quote .name.first}}, '{{date "2006-01-02" .created}}', {{default "NULL" .nick}});` + "\n",
+			want: "INSERT INTO t VALUES (1, \"Jane\", '2024-03-01', NULL);\n" +
+				"INSERT INTO t VALUES (2, \"Joe \\\"J\\\"\", '2024-12-24', jj);\n",
+		},
+		{
+			name:     "json and yaml",
+			template: `{{json .name}}|{{yaml .name}}` + "\n",
+			want:     "{\"first\":\"Jane\"}|first: Jane\n{\"first\":\"Joe \\\"J\\\"\"}|first: Joe \"J\"\n",
+		},
+		{
+			name:     "document",
+			template: `{{.total}} records: {{range $i, $r := .data}}{{if $i}}, {{end}}{{$r.id}}{{end}}` + "\n",
+			options:  []OptionFunc{WithArray(true)},
+			want:     "2 records: 1, 2\n",
+		},
+		{
+			name:     "document with nested property",
+			template: `<ul>{{range .people}}<li>{{html .name.first}}</li>{{end}}</ul>`,
+			options:  []OptionFunc{WithArray(true), WithNestedPropertyName("people")},
+			want:     "<ul><li>Jane</li><li>Joe &#34;J&#34;</li></ul>",
+		},
+		{
+			name:     "parse error",
+			template: `{{.id`,
+			wantErr:  true,
+		},
+		{
+			name:     "execution error",
+			template: `{{date "2006" .name.first}}`,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			templateFile := filepath.Join(t.TempDir(), "out.tmpl")
+			if err := os.WriteFile(templateFile, []byte(tt.template), 0600); err != nil {
+				t.Fatalf("Failed to write template file: %v", err)
+			}
+			data, err := mapToFile(t, mappingJSON, input, append([]OptionFunc{WithOutputType("template"), WithTemplate(templateFile)}, tt.options...)...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Map() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if string(data) != tt.want {
+				t.Errorf("Map() = %q, want %q", data, tt.want)
+			}
+		})
+	}
+}
+
+// TestTemplateFuncs tests the helper functions available to templates.
+func TestTemplateFuncs(t *testing.T) {
+	mapper := &Mapper{encoder: jsonEncoder{decimals: -1}}
+	funcs := mapper.templateFuncs()
+	join := funcs["join"].(func(string, any) (string, error))
+	if got, err := join(", ", []any{"a", 1, 1.5, true}); err != nil || got != "a, 1, 1.5, true" {
+		t.Errorf("join() = %q, %v", got, err)
+	}
+	if _, err := join(", ", "a"); err == nil {
+		t.Error("join() expected error for a string")
+	}
+	def := funcs["default"].(func(any, any) any)
+	if got := def("x", nil); got != "x" {
+		t.Errorf("default(nil) = %v", got)
+	}
+	if got := def("x", 0); got != 0 {
+		t.Errorf("default(0) = %v", got)
+	}
+	date := funcs["date"].(func(string, any) (string, error))
+	if got, err := date("2006-01-02 15:04", 86400); err != nil || got != "1970-01-02 00:00" {
+		t.Errorf("date() = %q, %v", got, err)
+	}
+}
+
+// TestTemplateOptions tests that template output and template file are used together.
+func TestTemplateOptions(t *testing.T) {
+	if _, err := NewMapper(WithOutputType("template")); err == nil {
+		t.Error("NewMapper() expected error without template file")
+	}
+	if _, err := NewMapper(WithOutputType("json"), WithTemplate("out.tmpl")); err == nil {
+		t.Error("NewMapper() expected error for template file without template output")
+	}
+}
@@ -1,12 +1,17 @@
 package csv2json
 
+import (
+	"io"
+	"regexp"
+)
+
 type (
 
 	// Mapper defines a structure for mapping input data to output data, applying configuration and marshaling as needed.
 	Mapper struct {
 
-		// in specifies the input file path or '-' for standard input in the mapping process.
-		in string
+		// in specifies the input file paths, glob patterns or '-' for standard input in the mapping process.
+		in []string
 
 		// out specifies the output file path or '-' for standard output in the mapping process.
 		out string
@@ -34,6 +39,138 @@ type (
 
 		// separator defines the byte value used as a delimiter or boundary in certain operations within the Mapper.
 		separator rune
+
+		// inputType specifies how the input is read. csv, fixed-width, jsonl, json or ods
+		inputType string
+
+		// sheet is the name or 1-based index of the sheet read from spreadsheet input
+		sheet string
+
+		// skipLines is the number of lines skipped at the beginning of every input
+		skipLines int
+
+		// skipUntil skips lines at the beginning of every input until a line matches
+		skipUntil *regexp.Regexp
+
+		// headerRow is the 1-based row of the header, rows before it are dropped
+		headerRow int
+
+		// skipTrailer is the number of rows dropped at the end of every input
+		skipTrailer int
+
+		// trailerPattern drops rows matching the pattern
+		trailerPattern *regexp.Regexp
+
+		// preamblePatterns holds the compiled patterns of the configured preamble variables
+		preamblePatterns []*regexp.Regexp
+
+		// preambleVariables holds the variables parsed from the preamble of the input currently processed
+		preambleVariables map[string]string
+
+		// columnKeys maps normalized header names and aliases to mapping keys
+		columnKeys map[string]string
+
+		// warnings receives warnings, defaults to standard error
+		warnings io.Writer
+
+		// encoder holds the options used to encode JSON output
+		encoder jsonEncoder
+
+		// ordered writes properties in the order they are declared in the mapping instead of sorted
+		ordered bool
+
+		// yamlStream writes every record as a separate YAML document instead of an array
+		yamlStream bool
+
+		// xmlRecordElement is the name of the element of every record in XML output
+		xmlRecordElement string
+
+		// xmlHeaderElement is the name of the element holding document-level fields in XML output
+		xmlHeaderElement string
+
+		// outputSeparator separates the columns of CSV output
+		outputSeparator rune
+
+		// flattenSeparator joins the parts of nested properties in the header of CSV output
+		flattenSeparator string
+
+		// quoting defines which cells of CSV output are quoted. minimal, strings or all
+		quoting string
+
+		// allowFormulas disables neutralizing text cells of CSV output that start like a spreadsheet formula
+		allowFormulas bool
+
+		// dialect is the SQL dialect of SQL output. postgres, mysql or sqlite
+		dialect string
+
+		// table is the table SQL output inserts into
+		table string
+
+		// batchSize is the number of records per INSERT statement
+		batchSize int
+
+		// upsertKeys lists the columns identifying existing rows updated instead of inserted
+		upsertKeys []string
+
+		// createTable writes a CREATE TABLE statement before the INSERT statements
+		createTable bool
+
+		// copyFormat is the format of PostgreSQL COPY output. text or binary
+		copyFormat string
+
+		// bulkAction is the action of bulk output. index, create or update
+		bulkAction string
+
+		// bulkIndexProperty names the property holding the _index of a bulk action
+		bulkIndexProperty string
+
+		// bulkIDProperty names the property holding the _id of a bulk action
+		bulkIDProperty string
+
+		// bulkMaxDocuments limits the number of documents per chunk of bulk output, 0 for no limit
+		bulkMaxDocuments int
+
+		// bulkMaxBytes limits the size of a chunk of bulk output, 0 for no limit
+		bulkMaxBytes int
+
+		// avroCodec is the compression codec of Avro output blocks. null or deflate
+		avroCodec string
+
+		// avroName is the name of the Avro record schema
+		avroName string
+
+		// avroSchemaFile is the file the derived Avro schema is written to, if set
+		avroSchemaFile string
+
+		// protoMessage is the name of the protobuf message of the records
+		protoMessage string
+
+		// protoFile is the file the derived .proto definition is written to, if set
+		protoFile string
+
+		// templateFile is the text/template file of template output
+		templateFile string
+
+		// maxWidth limits the width of table columns, longer cells are truncated. 0 for no limit
+		maxWidth int
+
+		// keyBy lists the properties whose values form the key of records in keyed object output
+		keyBy []string
+
+		// keySeparator joins the values of composite keys
+		keySeparator string
+
+		// duplicateKeys is the policy for records sharing a key. error, first, last or array
+		duplicateKeys string
+
+		// yamlDocument places document-level fields in a leading or trailing document of a YAML stream, empty to omit them
+		yamlDocument string
+
+		// currentIn holds the name of the input currently processed
+		currentIn string
+
+		// currentInRecord holds the record number within the input currently processed
+		currentInRecord int
 	}
 
 	// ColumnConfiguration defines the structure for configuring a column's property and type in a mapping.
@@ -44,6 +181,19 @@ type (
 
 		// Type specifies the data type of the column in the mapping configuration.
 		Type string `json:"type"`
+
+		// Aliases lists alternative header names resolving to this column when reading named input.
+		Aliases []string `json:"aliases"`
+
+		// Geometry designates the column as latitude, longitude or wkt geometry of GeoJSON output.
+		Geometry string `json:"geometry"`
+
+		// Optional marks a column that may be empty. Empty values are left out of the record and the Avro field
+		// of the column is nullable.
+		Optional bool `json:"optional"`
+
+		// FieldNumber is the field number of the property in protobuf messages, 0 assigns a number automatically.
+		FieldNumber int `json:"field_number"`
 	}
 
 	// CalculatedField defines a structure for representing dynamically computed fields within a configuration.
@@ -64,6 +214,9 @@ type (
 		// Location specifies the location or context where the calculated field applies in the mapping.
 		//Either document or record
 		Location string `json:"location"`
+
+		// FieldNumber is the field number of the property in protobuf messages, 0 assigns a number automatically.
+		FieldNumber int `json:"field_number"`
 	}
 
 	// ExtraVariable is used to store a static extra variable in the mapping
@@ -84,5 +237,125 @@ type (
 
 		// Mapping represents a map of keys to their corresponding column configurations in the mapping structure.
 		Mapping map[string]ColumnConfiguration `json:"mapping"`
+
+		// FixedWidth describes the column layout when reading fixed width input.
+		FixedWidth FixedWidthConfiguration `json:"fixed_width"`
+
+		// Preamble lists the variables parsed from skipped preamble lines.
+		Preamble []PreambleVariable `json:"preamble"`
+
+		// HeaderNormalization lists the rules applied to header names, mapping keys and aliases before they are compared.
+		// trim, case_fold, collapse_whitespace, remove_whitespace or strip_punctuation
+		HeaderNormalization []string `json:"header_normalization"`
+
+		// SchemaDrift configures how differences between the header and the mapping are reported.
+		SchemaDrift SchemaDriftConfiguration `json:"schema_drift"`
+
+		// RecordTypes configures inputs mixing rows of different record types. When set, Mapping is not used.
+		RecordTypes RecordTypesConfiguration `json:"record_types"`
+
+		// Order lists record properties written first in ordered output, using dotted names for nested properties.
+		Order []string `json:"order"`
+
+		// mappingKeys holds the keys of Mapping in the order of the mapping file
+		mappingKeys []string
+	}
+
+	// RecordTypesConfiguration defines how rows are assigned to record types using a discriminator column.
+	RecordTypesConfiguration struct {
+
+		// Discriminator is the index of the column holding the record type.
+		Discriminator int `json:"discriminator"`
+
+		// Unknown defines how rows with an unknown record type are treated. One of error (default) or skip
+		Unknown string `json:"unknown"`
+
+		// Types maps discriminator values to record types.
+		Types map[string]RecordType `json:"types"`
+
+		// typeNames holds the keys of Types in the order of the mapping file
+		typeNames []string
+	}
+
+	// RecordType defines the mapping of rows of a single record type.
+	RecordType struct {
+
+		// Columns is the number of columns rows of this type must have, 0 disables the check.
+		Columns int `json:"columns"`
+
+		// Location specifies where mapped rows are placed. Either record (default) or document
+		Location string `json:"location"`
+
+		// Property is the property of the document rows located in the document are stored in.
+		Property string `json:"property"`
+
+		// Repeated collects rows located in the document into an array, otherwise only a single row is allowed.
+		Repeated bool `json:"repeated"`
+
+		// Mapping maps column indices to column configurations for rows of this type.
+		Mapping map[string]ColumnConfiguration `json:"mapping"`
+
+		// Calculated lists calculated fields applied to rows of this type in addition to the record level calculated fields.
+		Calculated []CalculatedField `json:"calculated"`
+
+		// mappingKeys holds the keys of Mapping in the order of the mapping file
+		mappingKeys []string
+	}
+
+	// SchemaDriftConfiguration defines how each kind of difference between header and mapping is treated.
+	// Every setting is one of ignore (default), warn or error.
+	SchemaDriftConfiguration struct {
+
+		// Missing applies to mapped columns or calculated mapping source fields not found in the header.
+		Missing string `json:"missing"`
+
+		// Unmapped applies to header columns not used by the mapping.
+		Unmapped string `json:"unmapped"`
+
+		// Duplicate applies to header names occurring more than once.
+		Duplicate string `json:"duplicate"`
+	}
+
+	// PreambleVariable defines a variable whose value is taken from a skipped preamble line.
+	PreambleVariable struct {
+
+		// Name is the name of the variable, used as format of preamble calculated fields
+		Name string `json:"name"`
+
+		// Pattern is a regular expression matched against preamble lines. The first capturing group
+		// is the value of the variable, without a group the whole match is used.
+		Pattern string `json:"pattern"`
+	}
+
+	// FixedWidthConfiguration describes how fixed width text lines are split into columns.
+	FixedWidthConfiguration struct {
+
+		// Columns lists the columns of a line in the order they appear.
+		Columns []FixedWidthColumn `json:"columns"`
+
+		// Trim specifies which side of a value padding is removed from. One of none, left, right or both (default)
+		Trim string `json:"trim"`
+
+		// Padding lists the characters used for padding values, defaults to a space
+		Padding string `json:"padding"`
+	}
+
+	// FixedWidthColumn defines the position of a single column in a fixed width line.
+	FixedWidthColumn struct {
+
+		// Name is the column name used as mapping key when reading named input.
+		Name string `json:"name"`
+
+		// Start is the 0-based character offset of the column. When omitted the column follows the previous one.
+		Start *int `json:"start"`
+
+		// Length is the number of characters the column occupies.
+		Length int `json:"length"`
+
+		// Trim overrides the trim setting of the configuration for this column.
+		Trim string `json:"trim"`
+
+		// Padding overrides the padding characters of the configuration for this column.
+		Padding string `json:"padding"`
 	}
 )
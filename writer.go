@@ -0,0 +1,386 @@
+package csv2json
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// recordWriter writes mapped records to the output as they are produced.
+type recordWriter interface {
+
+	// write writes a single record
+	write(record map[string]any) error
+
+	// close completes the output, document holds the document-level properties written after the records
+	close(document map[string]any) error
+}
+
+// newRecordWriter returns the writer for the configured output. JSON and YAML arrays are streamed, so memory
+// does not grow with the number of records. TOML requires tables to follow plain values and is written at once.
+func (m *Mapper) newRecordWriter(out io.Writer, document map[string]any, recordOrder, documentOrder []string) (recordWriter, error) {
+	switch {
+	case m.marshalWith == "xml":
+		return m.newXMLWriter(out, recordOrder, documentOrder)
+	case m.marshalWith == "csv":
+		return m.newCSVWriter(out, recordOrder)
+	case m.marshalWith == "sql":
+		return m.newSQLWriter(out, recordOrder)
+	case m.marshalWith == "pgcopy":
+		return m.newPGCopyWriter(out, recordOrder)
+	case m.marshalWith == "bulk":
+		return m.newBulkWriter(out, recordOrder)
+	case m.marshalWith == "geojson":
+		return m.newGeoJSONWriter(out, recordOrder)
+	case m.marshalWith == "msgpack":
+		return m.newBinaryWriter(out, msgpackFormat{}, recordOrder, documentOrder)
+	case m.marshalWith == "cbor":
+		return m.newBinaryWriter(out, cborFormat{}, recordOrder, documentOrder)
+	case m.marshalWith == "avro":
+		return m.newAvroWriter(out, recordOrder)
+	case m.marshalWith == "protobuf":
+		return m.newProtobufWriter(out, recordOrder)
+	case m.marshalWith == "template":
+		return m.newTemplateWriter(out)
+	case m.marshalWith == "markdown" || m.marshalWith == "html" || m.marshalWith == "table":
+		return m.newTableWriter(out, recordOrder)
+	case len(m.keyBy) > 0:
+		return m.newKeyedWriter(out, recordOrder, documentOrder), nil
+	case m.yamlStream:
+		return &yamlStreamWriter{mapper: m, encoder: yaml.NewEncoder(out), document: document, recordOrder: recordOrder, documentOrder: documentOrder}, nil
+	case !m.array:
+		return &documentWriter{mapper: m, out: out, order: recordOrder}, nil
+	case m.marshalWith == "json":
+		return &jsonArrayWriter{mapper: m, out: out, property: m.documentProperty(), recordOrder: recordOrder, documentOrder: documentOrder}, nil
+	case m.marshalWith == "yaml":
+		return &yamlArrayWriter{mapper: m, out: out, property: m.documentProperty(), recordOrder: recordOrder, documentOrder: documentOrder}, nil
+	}
+	return &bufferedWriter{mapper: m, out: out, documentOrder: documentOrder}, nil
+}
+
+// documentProperty returns the property holding the records if they are written as part of a document,
+// an empty string if the records are written as a plain array.
+func (m *Mapper) documentProperty() string {
+	if m.nestedPropertyName != "" {
+		return m.nestedPropertyName
+	}
+	if m.marshalWith == "toml" || m.marshalWith == "xml" || m.marshalWith == "template" {
+		return "data"
+	}
+	return ""
+}
+
+// recordOutputs are the output types writing records only, they can neither be written as array nor hold
+// document-level fields
+var recordOutputs = []string{"csv", "sql", "pgcopy", "bulk", "avro", "protobuf", "markdown", "html", "table"}
+
+// validateDocumentFields rejects document-level calculated fields for output types without a document, as their
+// values would be lost.
+func (m *Mapper) validateDocumentFields() error {
+	if !slices.Contains(recordOutputs, m.marshalWith) && m.marshalWith != "geojson" {
+		return nil
+	}
+	for _, field := range m.configuration.Calculated {
+		if field.Location == "document" {
+			return fmt.Errorf("calculated field %q is located in the document, which %s output does not have", field.Property, m.marshalWith)
+		}
+	}
+	return nil
+}
+
+// writesDocument reports whether the output has a document holding the document-level fields and the rows of
+// record types located in the document.
+func (m *Mapper) writesDocument() bool {
+	return (m.array && m.documentProperty() != "") || m.marshalWith == "xml" || m.yamlDocument != ""
+}
+
+// documentWriter writes every record as a separate document, separated by a newline.
+type documentWriter struct {
+	mapper  *Mapper
+	out     io.Writer
+	order   []string
+	written int
+}
+
+// write marshals the record and writes it as a document.
+func (w *documentWriter) write(record map[string]any) error {
+	d, err := w.mapper.marshaler(w.mapper.order(record, w.order))
+	if err != nil {
+		return err
+	}
+	if w.written > 0 {
+		d = append([]byte("\n"), d...)
+	}
+	w.written++
+	_, err = w.out.Write(d)
+	return err
+}
+
+// close does nothing, as every document is complete.
+func (w *documentWriter) close(map[string]any) error {
+	return nil
+}
+
+// jsonArrayWriter streams records as a JSON array. With a property the array is the first property of a
+// document, the remaining document properties are written when the writer is closed.
+type jsonArrayWriter struct {
+	mapper        *Mapper
+	out           io.Writer
+	property      string
+	recordOrder   []string
+	documentOrder []string
+	written       int
+	buf           bytes.Buffer
+}
+
+// depth returns the nesting depth of the records.
+func (w *jsonArrayWriter) depth() int {
+	if w.property != "" {
+		return 2
+	}
+	return 1
+}
+
+// start writes the opening of the document and the array.
+func (w *jsonArrayWriter) start() {
+	if w.property != "" {
+		w.buf.WriteByte('{')
+		w.mapper.encoder.newline(&w.buf, 1)
+		w.mapper.encoder.encodeString(&w.buf, w.property)
+		w.buf.WriteByte(':')
+		if w.mapper.encoder.indent > 0 {
+			w.buf.WriteByte(' ')
+		}
+	}
+	w.buf.WriteByte('[')
+}
+
+// write encodes the record as the next element of the array.
+func (w *jsonArrayWriter) write(record map[string]any) error {
+	w.buf.Reset()
+	if w.written == 0 {
+		w.start()
+	} else {
+		w.buf.WriteByte(',')
+	}
+	w.mapper.encoder.newline(&w.buf, w.depth())
+	if err := w.mapper.encoder.encode(&w.buf, w.mapper.order(record, w.recordOrder), w.depth()); err != nil {
+		return err
+	}
+	w.written++
+	_, err := w.out.Write(w.buf.Bytes())
+	return err
+}
+
+// close ends the array and writes the remaining document properties.
+func (w *jsonArrayWriter) close(document map[string]any) error {
+	w.buf.Reset()
+	if w.written == 0 {
+		w.start()
+	} else {
+		w.mapper.encoder.newline(&w.buf, w.depth()-1)
+	}
+	w.buf.WriteByte(']')
+	if w.property != "" {
+		keys, values := members(w.mapper.order(document, w.documentOrder))
+		for _, key := range keys {
+			if key == w.property {
+				continue
+			}
+			w.buf.WriteByte(',')
+			w.mapper.encoder.newline(&w.buf, 1)
+			w.mapper.encoder.encodeString(&w.buf, key)
+			w.buf.WriteByte(':')
+			if w.mapper.encoder.indent > 0 {
+				w.buf.WriteByte(' ')
+			}
+			if err := w.mapper.encoder.encode(&w.buf, values[key], 1); err != nil {
+				return err
+			}
+		}
+		w.mapper.encoder.newline(&w.buf, 0)
+		w.buf.WriteByte('}')
+	}
+	_, err := w.out.Write(w.buf.Bytes())
+	return err
+}
+
+// members returns the keys of an object in the order they are written and its values.
+func members(value any) ([]string, map[string]any) {
+	if ordered, ok := value.(orderedMap); ok {
+		return ordered.keys, ordered.values
+	}
+	values, _ := value.(map[string]any)
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys, values
+}
+
+// yamlArrayWriter streams records as a YAML sequence. With a property the sequence is the first property of a
+// mapping, the remaining document properties are written when the writer is closed.
+type yamlArrayWriter struct {
+	mapper        *Mapper
+	out           io.Writer
+	property      string
+	recordOrder   []string
+	documentOrder []string
+	written       int
+}
+
+// write encodes the record as the next element of the sequence. Every record is encoded as a sequence
+// holding only this record, sequences of single records concatenate to the complete sequence.
+func (w *yamlArrayWriter) write(record map[string]any) error {
+	var value any = []any{w.mapper.order(record, w.recordOrder)}
+	if w.property != "" {
+		value = map[string]any{w.property: value}
+	}
+	d, err := yaml.Marshal(value)
+	if err != nil {
+		return err
+	}
+	if w.property != "" && w.written > 0 {
+		// the first line holds the property, which is written with the first record only
+		d = d[bytes.IndexByte(d, '\n')+1:]
+	}
+	w.written++
+	_, err = w.out.Write(d)
+	return err
+}
+
+// close writes an empty sequence if there were no records and the remaining document properties.
+func (w *yamlArrayWriter) close(document map[string]any) error {
+	if w.written == 0 {
+		var value any = []any{}
+		if w.property != "" {
+			value = map[string]any{w.property: value}
+		}
+		d, err := yaml.Marshal(value)
+		if err != nil {
+			return err
+		}
+		if _, err = w.out.Write(d); err != nil {
+			return err
+		}
+	}
+	if w.property == "" {
+		return nil
+	}
+	rest := make(map[string]any, len(document))
+	for key, value := range document {
+		if key != w.property {
+			rest[key] = value
+		}
+	}
+	if len(rest) == 0 {
+		return nil
+	}
+	d, err := yaml.Marshal(w.mapper.order(rest, w.documentOrder))
+	if err != nil {
+		return err
+	}
+	_, err = w.out.Write(d)
+	return err
+}
+
+// yamlStreamWriter writes every record as a separate document of a YAML stream. Document-level fields are
+// written as a leading document before the first record or as a trailing document after the last one.
+type yamlStreamWriter struct {
+	mapper        *Mapper
+	encoder       *yaml.Encoder
+	document      map[string]any
+	recordOrder   []string
+	documentOrder []string
+	started       bool
+}
+
+// write encodes the record as the next document, preceded by the leading document for the first record.
+func (w *yamlStreamWriter) write(record map[string]any) error {
+	if err := w.start(); err != nil {
+		return err
+	}
+	return w.encoder.Encode(w.mapper.order(record, w.recordOrder))
+}
+
+// start writes the leading document once. It holds the document-level calculated fields.
+func (w *yamlStreamWriter) start() error {
+	if w.started || w.mapper.yamlDocument != "leading" {
+		return nil
+	}
+	w.started = true
+	document, err := w.mapper.applyCalculatedFields(w.mapper.configuration.Calculated, nil, nil, 0, maps.Clone(w.document), "document")
+	if err != nil {
+		return err
+	}
+	if len(document) == 0 {
+		return nil
+	}
+	return w.encoder.Encode(w.mapper.order(document, w.documentOrder))
+}
+
+// close writes the leading document if there were no records or the trailing document and ends the stream.
+func (w *yamlStreamWriter) close(document map[string]any) error {
+	if err := w.start(); err != nil {
+		return err
+	}
+	if w.mapper.yamlDocument == "trailing" && len(document) > 0 {
+		if err := w.encoder.Encode(w.mapper.order(document, w.documentOrder)); err != nil {
+			return err
+		}
+	}
+	return w.encoder.Close()
+}
+
+// validateYAMLDocument ensures a leading document does not use values only known after all records are read.
+// Rows of record types located in the document may follow the first record, so they require a trailing document.
+func (m *Mapper) validateYAMLDocument() error {
+	if m.yamlDocument != "leading" {
+		return nil
+	}
+	for _, name := range slices.Sorted(maps.Keys(m.configuration.RecordTypes.Types)) {
+		if m.configuration.RecordTypes.Types[name].Location == "document" {
+			return fmt.Errorf("record type %q is located in the document and requires a trailing document", name)
+		}
+	}
+	for _, field := range m.configuration.Calculated {
+		if field.Location == "document" && field.Kind == "application" && field.Format == "records" {
+			return fmt.Errorf("calculated field %q counts the records and requires a trailing document", field.Property)
+		}
+	}
+	return nil
+}
+
+// bufferedWriter collects all records and writes them as a single document when it is closed.
+type bufferedWriter struct {
+	mapper        *Mapper
+	out           io.Writer
+	documentOrder []string
+	records       []map[string]any
+}
+
+// write adds the record to the document.
+func (w *bufferedWriter) write(record map[string]any) error {
+	w.records = append(w.records, record)
+	return nil
+}
+
+// close marshals the document including all records.
+func (w *bufferedWriter) close(document map[string]any) error {
+	records := w.records
+	if records == nil {
+		records = make([]map[string]any, 0)
+	}
+	document[w.mapper.documentProperty()] = records
+	d, err := w.mapper.marshaler(w.mapper.order(document, w.documentOrder))
+	if err != nil {
+		return err
+	}
+	_, err = w.out.Write(d)
+	return err
+}
@@ -0,0 +1,331 @@
+package csv2json
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// TestMapStreamingArray tests array output written record by record for JSON and YAML.
+func TestMapStreamingArray(t *testing.T) {
+	mappingJSON := `{
+		"mapping": {
+			"0": {"property": "id", "type": "int"},
+			"1": {"property": "name", "type": "string"}
+		},
+		"calculated": [
+			{"property": "_meta.records", "kind": "application", "format": "records", "type": "int", "location": "document"}
+		]
+	}`
+
+	tests := []struct {
+		name       string
+		input      string
+		outputType string
+		nested     string
+		indent     int
+		want       string
+	}{
+		{
+			name:       "json array",
+			input:      "1,a\n2,b\n",
+			outputType: "json",
+			want:       `[{"id":1,"name":"a"},{"id":2,"name":"b"}]`,
+		},
+		{
+			name:       "empty json array",
+			outputType: "json",
+			want:       `[]`,
+		},
+		{
+			name:       "nested json",
+			input:      "1,a\n2,b\n",
+			outputType: "json",
+			nested:     "data",
+			want:       `{"data":[{"id":1,"name":"a"},{"id":2,"name":"b"}],"_meta":{"records":2}}`,
+		},
+		{
+			name:       "empty nested json with indent",
+			outputType: "json",
+			nested:     "data",
+			indent:     2,
+			want:       "{\n  \"data\": [],\n  \"_meta\": {\n    \"records\": 0\n  }\n}",
+		},
+		{
+			name:       "nested json with indent",
+			input:      "1,a\n",
+			outputType: "json",
+			nested:     "data",
+			indent:     2,
+			want:       "{\n  \"data\": [\n    {\n      \"id\": 1,\n      \"name\": \"a\"\n    }\n  ],\n  \"_meta\": {\n    \"records\": 1\n  }\n}",
+		},
+		{
+			name:       "yaml sequence",
+			input:      "1,a\n2,b\n",
+			outputType: "yaml",
+			want:       "- id: 1\n  name: a\n- id: 2\n  name: b\n",
+		},
+		{
+			name:       "empty yaml sequence",
+			outputType: "yaml",
+			want:       "[]\n",
+		},
+		{
+			name:       "nested yaml",
+			input:      "1,a\n2,b\n",
+			outputType: "yaml",
+			nested:     "data",
+			want:       "data:\n    - id: 1\n      name: a\n    - id: 2\n      name: b\n_meta:\n    records: 2\n",
+		},
+		{
+			name:       "toml",
+			input:      "1,a\n",
+			outputType: "toml",
+			want:       "[_meta]\n  records = 1\n\n[[data]]\n  id = 1\n  name = \"a\"\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := mapToFile(t, mappingJSON, tt.input,
+				WithArray(true),
+				WithOutputType(tt.outputType),
+				WithNestedPropertyName(tt.nested),
+				WithIndent(tt.indent),
+			)
+			if err != nil {
+				t.Fatalf("Failed to map: %v", err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("Map() = %q, want %q", data, tt.want)
+			}
+		})
+	}
+}
+
+// TestJSONArrayWriterStreams tests that records are written before the array is closed.
+func TestJSONArrayWriterStreams(t *testing.T) {
+	var out bytes.Buffer
+	m := &Mapper{array: true, marshalWith: "json", nestedPropertyName: "data", encoder: jsonEncoder{decimals: -1}}
+	writer, err := m.newRecordWriter(&out, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("newRecordWriter() error = %v", err)
+	}
+	if err := writer.write(map[string]any{"id": 1}); err != nil {
+		t.Fatalf("write() error = %v", err)
+	}
+	if got, want := out.String(), `{"data":[{"id":1}`; got != want {
+		t.Errorf("after first record = %q, want %q", got, want)
+	}
+	if err := writer.write(map[string]any{"id": 2}); err != nil {
+		t.Fatalf("write() error = %v", err)
+	}
+	if err := writer.close(map[string]any{"count": 2}); err != nil {
+		t.Fatalf("close() error = %v", err)
+	}
+	if got, want := out.String(), `{"data":[{"id":1},{"id":2}],"count":2}`; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+// TestMapYAMLStream tests writing records as a YAML multi-document stream.
+func TestMapYAMLStream(t *testing.T) {
+	mappingJSON := `{
+		"mapping": {
+			"0": {"property": "id", "type": "int"}
+		},
+		"calculated": [
+			{"property": "source", "kind": "extra", "format": "source", "type": "string", "location": "document"}
+			%s
+		],
+		"extra_variables": {"source": {"value": "crm"}}
+	}`
+	records := `, {"property": "records", "kind": "application", "format": "records", "type": "int", "location": "document"}`
+
+	tests := []struct {
+		name       string
+		calculated string
+		document   string
+		input      string
+		want       string
+		errString  string
+	}{
+		{
+			name:  "records only",
+			input: "1\n2\n",
+			want:  "id: 1\n---\nid: 2\n",
+		},
+		{
+			name:     "leading document",
+			document: "leading",
+			input:    "1\n2\n",
+			want:     "source: crm\n---\nid: 1\n---\nid: 2\n",
+		},
+		{
+			name:       "trailing document",
+			calculated: records,
+			document:   "trailing",
+			input:      "1\n2\n",
+			want:       "id: 1\n---\nid: 2\n---\nrecords: 2\nsource: crm\n",
+		},
+		{
+			name:     "leading document without records",
+			document: "leading",
+			want:     "source: crm\n",
+		},
+		{
+			name:       "leading document counting records",
+			calculated: records,
+			document:   "leading",
+			errString:  `calculated field "records" counts the records`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := mapToFile(t, fmt.Sprintf(mappingJSON, tt.calculated), tt.input,
+				WithOutputType("yaml"),
+				WithYAMLStream(true),
+				WithYAMLDocument(tt.document),
+			)
+			if tt.errString != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errString) {
+					t.Fatalf("Map() error = %v, should contain %v", err, tt.errString)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to map: %v", err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("Map() = %q, want %q", data, tt.want)
+			}
+		})
+	}
+}
+
+// TestMapYAMLStreamRecordTypes tests writing rows of record types located in the document to a YAML stream.
+func TestMapYAMLStreamRecordTypes(t *testing.T) {
+	mappingJSON := `{
+		"record_types": {
+			"discriminator": 0,
+			"types": {
+				"H": {"location": "document", "property": "header", "mapping": {"1": {"property": "bank", "type": "string"}}},
+				"D": {"mapping": {"1": {"property": "id", "type": "int"}}},
+				"T": {"location": "document", "property": "trailer", "mapping": {"1": {"property": "count", "type": "int"}}}
+			}
+		}
+	}`
+	input := "H,ACME\nD,1\nD,2\nT,2\n"
+
+	tests := []struct {
+		name      string
+		document  string
+		want      string
+		errString string
+	}{
+		{
+			name:     "trailing document",
+			document: "trailing",
+			want:     "id: 1\n---\nid: 2\n---\nheader:\n    bank: ACME\ntrailer:\n    count: 2\n",
+		},
+		{
+			name:      "leading document",
+			document:  "leading",
+			errString: `record type "H" is located in the document and requires a trailing document`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := mapToFile(t, mappingJSON, input, WithOutputType("yaml"), WithYAMLStream(true), WithYAMLDocument(tt.document))
+			if tt.errString != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errString) {
+					t.Fatalf("Map() error = %v, should contain %v", err, tt.errString)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to map: %v", err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("Map() = %q, want %q", data, tt.want)
+			}
+		})
+	}
+}
+
+// TestYAMLStreamOptions tests the validation of the YAML stream options.
+func TestYAMLStreamOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []OptionFunc
+		wantErr bool
+	}{
+		{
+			name:    "yaml stream",
+			options: []OptionFunc{WithOutputType("yaml"), WithYAMLStream(true), WithYAMLDocument("trailing")},
+		},
+		{
+			name:    "json output",
+			options: []OptionFunc{WithOutputType("json"), WithYAMLStream(true)},
+			wantErr: true,
+		},
+		{
+			name:    "array",
+			options: []OptionFunc{WithOutputType("yaml"), WithArray(true), WithYAMLStream(true)},
+			wantErr: true,
+		},
+		{
+			name:    "document without stream",
+			options: []OptionFunc{WithOutputType("yaml"), WithYAMLDocument("leading")},
+			wantErr: true,
+		},
+		{
+			name:    "unknown position",
+			options: []OptionFunc{WithOutputType("yaml"), WithYAMLStream(true), WithYAMLDocument("middle")},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mapper, err := NewMapper(tt.options...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewMapper() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && mapper.array {
+				t.Errorf("NewMapper() array = true, want false for a yaml stream")
+			}
+		})
+	}
+}
+
+// TestValidateDocumentFields tests rejecting document-level calculated fields for outputs without a document.
+func TestValidateDocumentFields(t *testing.T) {
+	document := []CalculatedField{{Property: "records", Kind: "application", Format: "records", Type: "int", Location: "document"}}
+	record := []CalculatedField{{Property: "record", Kind: "application", Format: "record", Type: "int", Location: "record"}}
+
+	tests := []struct {
+		name        string
+		marshalWith string
+		calculated  []CalculatedField
+		wantErr     bool
+	}{
+		{name: "json", marshalWith: "json", calculated: document},
+		{name: "csv record field", marshalWith: "csv", calculated: record},
+		{name: "csv", marshalWith: "csv", calculated: document, wantErr: true},
+		{name: "geojson", marshalWith: "geojson", calculated: document, wantErr: true},
+		{name: "table", marshalWith: "table", calculated: document, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Mapper{marshalWith: tt.marshalWith, configuration: Configuration{Calculated: tt.calculated}}
+			if err := m.validateDocumentFields(); (err != nil) != tt.wantErr {
+				t.Errorf("validateDocumentFields() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
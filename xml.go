@@ -0,0 +1,221 @@
+package csv2json
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// xmlName matches the element and attribute names written to XML output
+var xmlName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9._-]*$`)
+
+// xmlWriter writes records as child elements of a root element. Properties become nested elements, properties
+// whose name starts with @ become attributes of the enclosing element. Document-level fields are written as a
+// header element before the records, in that case the records are spooled to a temporary file until all
+// values of the header are known.
+type xmlWriter struct {
+	mapper        *Mapper
+	out           io.Writer
+	recordOrder   []string
+	documentOrder []string
+
+	// spool holds the records while the header is not yet known
+	spool *os.File
+
+	// started is set once the root element was opened
+	started bool
+
+	buf bytes.Buffer
+}
+
+// newXMLWriter creates an xmlWriter, spooling records if document-level fields are configured.
+func (m *Mapper) newXMLWriter(out io.Writer, recordOrder, documentOrder []string) (*xmlWriter, error) {
+	w := &xmlWriter{mapper: m, out: out, recordOrder: recordOrder, documentOrder: documentOrder}
+	if m.hasDocumentFields() {
+		spool, err := os.CreateTemp("", "csv2json-*.xml")
+		if err != nil {
+			return nil, err
+		}
+		w.spool = spool
+	}
+	return w, nil
+}
+
+// hasDocumentFields reports whether document-level calculated fields or record types located in the document
+// are configured.
+func (m *Mapper) hasDocumentFields() bool {
+	for _, field := range m.configuration.Calculated {
+		if field.Location == "document" {
+			return true
+		}
+	}
+	for _, recordType := range m.configuration.RecordTypes.Types {
+		if recordType.Location == "document" {
+			return true
+		}
+	}
+	return false
+}
+
+// start writes the XML declaration and opens the root element.
+func (w *xmlWriter) start() {
+	if w.started {
+		return
+	}
+	w.started = true
+	w.buf.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	w.buf.WriteString("<" + w.mapper.documentProperty() + ">")
+}
+
+// write writes the record as a record element, directly or to the spool.
+func (w *xmlWriter) write(record map[string]any) error {
+	w.buf.Reset()
+	out := w.out
+	if w.spool != nil {
+		out = w.spool
+	} else {
+		w.start()
+	}
+	w.mapper.encoder.newline(&w.buf, 1)
+	if err := w.element(w.mapper.xmlRecordElement, w.mapper.order(record, w.recordOrder), 1); err != nil {
+		return err
+	}
+	_, err := out.Write(w.buf.Bytes())
+	return err
+}
+
+// close writes the header element and the spooled records if needed and closes the root element.
+func (w *xmlWriter) close(document map[string]any) error {
+	w.buf.Reset()
+	w.start()
+	if w.spool != nil {
+		header := make(map[string]any, len(document))
+		for key, value := range document {
+			if key != w.mapper.documentProperty() {
+				header[key] = value
+			}
+		}
+		w.mapper.encoder.newline(&w.buf, 1)
+		if err := w.element(w.mapper.xmlHeaderElement, w.mapper.order(header, w.documentOrder), 1); err != nil {
+			return err
+		}
+		if _, err := w.out.Write(w.buf.Bytes()); err != nil {
+			return err
+		}
+		w.buf.Reset()
+		if _, err := w.spool.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		if _, err := io.Copy(w.out, w.spool); err != nil {
+			return err
+		}
+	}
+	w.mapper.encoder.newline(&w.buf, 0)
+	w.buf.WriteString("</" + w.mapper.documentProperty() + ">\n")
+	_, err := w.out.Write(w.buf.Bytes())
+	return err
+}
+
+// removeSpool closes and removes the spool file, if any.
+func (w *xmlWriter) removeSpool() {
+	if w.spool == nil {
+		return
+	}
+	_ = w.spool.Close()
+	_ = os.Remove(w.spool.Name())
+	w.spool = nil
+}
+
+// element writes value as an element with the given name. Objects write their properties as attributes and
+// child elements, arrays repeat the element for every value.
+func (w *xmlWriter) element(name string, value any, depth int) error {
+	if !xmlName.MatchString(name) {
+		return fmt.Errorf("%q is not a valid XML element name", name)
+	}
+	switch v := value.(type) {
+	case []any:
+		return w.elements(name, v, depth)
+	case []map[string]any:
+		values := make([]any, len(v))
+		for i := range v {
+			values[i] = v[i]
+		}
+		return w.elements(name, values, depth)
+	case map[string]any, orderedMap:
+		keys, values := members(v)
+		w.buf.WriteString("<" + name)
+		var children []string
+		for _, key := range keys {
+			attribute, ok := strings.CutPrefix(key, "@")
+			if !ok {
+				children = append(children, key)
+				continue
+			}
+			if !xmlName.MatchString(attribute) {
+				return fmt.Errorf("%q is not a valid XML attribute name", attribute)
+			}
+			text, err := xmlText(values[key])
+			if err != nil {
+				return fmt.Errorf("attribute %q: %w", attribute, err)
+			}
+			w.buf.WriteString(" " + attribute + `="`)
+			_ = xml.EscapeText(&w.buf, []byte(text))
+			w.buf.WriteByte('"')
+		}
+		if len(children) == 0 {
+			w.buf.WriteString("/>")
+			return nil
+		}
+		w.buf.WriteByte('>')
+		for _, key := range children {
+			w.mapper.encoder.newline(&w.buf, depth+1)
+			if err := w.element(key, values[key], depth+1); err != nil {
+				return err
+			}
+		}
+		w.mapper.encoder.newline(&w.buf, depth)
+		w.buf.WriteString("</" + name + ">")
+	default:
+		text, err := xmlText(v)
+		if err != nil {
+			return fmt.Errorf("element %q: %w", name, err)
+		}
+		w.buf.WriteString("<" + name + ">")
+		_ = xml.EscapeText(&w.buf, []byte(text))
+		w.buf.WriteString("</" + name + ">")
+	}
+	return nil
+}
+
+// elements writes an element for every value of an array.
+func (w *xmlWriter) elements(name string, values []any, depth int) error {
+	for i, value := range values {
+		if i > 0 {
+			w.mapper.encoder.newline(&w.buf, depth)
+		}
+		if err := w.element(name, value, depth); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// xmlText returns the text of a scalar value.
+func xmlText(value any) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case map[string]any, orderedMap, []any, []map[string]any:
+		return "", fmt.Errorf("expected a single value, found %T", value)
+	}
+	return fmt.Sprint(value), nil
+}
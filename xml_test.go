@@ -0,0 +1,109 @@
+package csv2json
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+// TestMapXML tests XML output with nested elements, attributes, escaping and a header element.
+func TestMapXML(t *testing.T) {
+	mapping := `{
+		"mapping": {
+			"0": {"property": "@id", "type": "int"},
+			"1": {"property": "name", "type": "string"},
+			"2": {"property": "address.city", "type": "string"},
+			"3": {"property": "address.@type", "type": "string"}
+		}
+		%s
+	}`
+	header := `, "calculated": [
+		{"property": "count", "kind": "application", "format": "records", "type": "int", "location": "document"}
+	]`
+
+	tests := []struct {
+		name       string
+		calculated string
+		input      string
+		options    []OptionFunc
+		want       string
+		errString  string
+	}{
+		{
+			name:  "records",
+			input: "1,Jane & <Co>,Berlin,home\n2,\"Joe \"\"J\"\"\",,\n",
+			want: `<?xml version="1.0" encoding="UTF-8"?>
+<data><record id="1"><address type="home"><city>Berlin</city></address><name>Jane &amp; &lt;Co&gt;</name></record><record id="2"><address type=""><city></city></address><name>Joe &#34;J&#34;</name></record></data>
+`,
+		},
+		{
+			name:    "custom names and indent",
+			input:   "1,Jane,Berlin,home\n",
+			options: []OptionFunc{WithNestedPropertyName("people"), WithRecordElement("person"), WithIndent(2), WithOrdered(true)},
+			want: `<?xml version="1.0" encoding="UTF-8"?>
+<people>
+  <person id="1">
+    <name>Jane</name>
+    <address type="home">
+      <city>Berlin</city>
+    </address>
+  </person>
+</people>
+`,
+		},
+		{
+			name:       "header element",
+			calculated: header,
+			input:      "1,Jane,Berlin,home\n",
+			options:    []OptionFunc{WithHeaderElement("meta")},
+			want: `<?xml version="1.0" encoding="UTF-8"?>
+<data><meta><count>1</count></meta><record id="1"><address type="home"><city>Berlin</city></address><name>Jane</name></record></data>
+`,
+		},
+		{
+			name: "no records",
+			want: `<?xml version="1.0" encoding="UTF-8"?>
+<data></data>
+`,
+		},
+		{
+			name:      "invalid root",
+			options:   []OptionFunc{WithNestedPropertyName("my records")},
+			errString: `"my records" is not a valid XML element name`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := mapToFile(t, strings.Replace(mapping, "%s", tt.calculated, 1), tt.input, append([]OptionFunc{WithOutputType("xml")}, tt.options...)...)
+			if tt.errString != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errString) {
+					t.Fatalf("Map() error = %v, should contain %v", err, tt.errString)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to map: %v", err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("Map() = %q, want %q", data, tt.want)
+			}
+			var document struct{}
+			if err := xml.Unmarshal(data, &document); err != nil {
+				t.Errorf("Map() wrote invalid XML: %v", err)
+			}
+		})
+	}
+}
+
+// TestXMLWriterElementNames tests that property names not usable as XML names are rejected.
+func TestXMLWriterElementNames(t *testing.T) {
+	m := &Mapper{xmlRecordElement: "record"}
+	w := &xmlWriter{mapper: m}
+	for _, property := range []string{"first name", "1st", "@a b"} {
+		w.buf.Reset()
+		if err := w.element("record", map[string]any{property: "x"}, 1); err == nil {
+			t.Errorf("element() with property %q error = nil, want error", property)
+		}
+	}
+}